@@ -1,13 +1,19 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/thebenkogan/lox-interpreter/internal/astjson"
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
 	"github.com/thebenkogan/lox-interpreter/internal/interpreter"
 	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/optimizer"
 	"github.com/thebenkogan/lox-interpreter/internal/parser"
 )
 
@@ -19,21 +25,95 @@ func main() {
 }
 
 func run(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("Usage: %s <command> [flags] <file> [args...]", args[0])
+	}
 	command := args[1]
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	format := flags.String("format", "text", "output format for tokenize and parse: text or json")
+	var readRoots, writeRoots pathList
+	flags.Var(&readRoots, "allow-read", "comma-separated directories that scripts may read files from")
+	flags.Var(&writeRoots, "allow-write", "comma-separated directories that scripts may write files to")
+	optimize := flags.Bool("optimize", false, "fold constants and remove dead branches before running")
+	maxDepth := flags.Int("max-depth", evaluator.DefaultMaxCallDepth, "how deeply function calls can nest before a stack overflow")
+	maxMemory := flags.Int("max-memory", 0, "approximate bytes a script may allocate in total, or 0 for no limit")
+	profilePath := flags.String("profile", "", "file to write a pprof profile of the Lox functions called to, for execute and run-ast")
+	coverageDir := flags.String("coverage", "", "directory to write an LCOV report (lcov.info) and an annotated source view (coverage.html) of the statements run to, for execute")
+	seed := flags.Uint64("seed", 0, "seed for the random natives, to make runs reproducible")
+	if err := flags.Parse(args[2:]); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("Unknown format: %s", *format)
+	}
+	options := []interpreter.Option{
+		interpreter.WithReadRoots(readRoots...),
+		interpreter.WithWriteRoots(writeRoots...),
+	}
+	if *maxDepth < 1 {
+		return fmt.Errorf("--max-depth must be at least 1")
+	}
+	options = append(options, interpreter.WithMaxCallDepth(*maxDepth))
+	if *maxMemory < 0 {
+		return fmt.Errorf("--max-memory must not be negative")
+	}
+	options = append(options, interpreter.WithMemoryLimit(*maxMemory))
+	if *optimize {
+		options = append(options, interpreter.WithOptimizer())
+	}
+	if *profilePath != "" {
+		if command != "execute" && command != "run-ast" {
+			return fmt.Errorf("--profile is only supported by execute and run-ast")
+		}
+		options = append(options, interpreter.WithProfiling())
+	}
+	if *coverageDir != "" {
+		if command != "execute" {
+			return fmt.Errorf("--coverage is only supported by execute")
+		}
+		if *optimize {
+			return fmt.Errorf("--coverage cannot be combined with --optimize, which removes statements from the report")
+		}
+		options = append(options, interpreter.WithCoverage())
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			options = append(options, interpreter.WithSeed(*seed))
+		}
+	})
+
 	if command == "repl" {
-		return repl()
+		return repl(append(options, interpreter.WithArgs(flags.Args()...))...)
 	}
+	if flags.NArg() < 1 {
+		return fmt.Errorf("Usage: %s %s [flags] <file or - for stdin> [args...]", args[0], command)
+	}
+	options = append(options, interpreter.WithArgs(flags.Args()[1:]...))
 
-	file, err := os.Open(args[2])
-	if err != nil {
-		return fmt.Errorf("Error opening file: %w", err)
+	file := os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		opened, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("Error opening file: %w", err)
+		}
+		defer opened.Close()
+		file = opened
 	}
 
 	switch command {
 	case "tokenize":
 		tokens, lexerErr := lexer.Tokenize(file)
-		for _, token := range tokens {
-			fmt.Println(token.String())
+		if *format == "json" {
+			encoded, err := json.MarshalIndent(tokens, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(encoded))
+		} else {
+			for _, token := range tokens {
+				fmt.Println(token.String())
+			}
 		}
 		if lexerErr != nil {
 			fmt.Fprint(os.Stderr, lexerErr.Error())
@@ -50,12 +130,38 @@ func run(args []string) error {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
 			os.Exit(65)
 		}
-		for _, statement := range expr {
-			fmt.Println(statement.String())
+		if *optimize {
+			expr = optimizer.Optimize(expr)
+		}
+		if *format == "json" {
+			encoded, err := astjson.Marshal(expr)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(encoded))
+		} else {
+			for _, statement := range expr {
+				fmt.Println(statement.String())
+			}
 		}
 	case "execute":
-		interpreter := interpreter.NewInterpreter(os.Stdout)
+		interpreter, setupErr := interpreter.NewInterpreter(os.Stdin, os.Stdout, options...)
+		if setupErr != nil {
+			return setupErr
+		}
 		err := interpreter.Interpret(file)
+		saveReports(interpreter, *profilePath, *coverageDir, flags.Arg(0), err == nil)
+		if err != nil {
+			fmt.Fprint(os.Stderr, err.Error())
+			os.Exit(err.Code())
+		}
+	case "run-ast":
+		interpreter, setupErr := interpreter.NewInterpreter(os.Stdin, os.Stdout, options...)
+		if setupErr != nil {
+			return setupErr
+		}
+		err := interpreter.InterpretAST(file)
+		saveReports(interpreter, *profilePath, "", flags.Arg(0), err == nil)
 		if err != nil {
 			fmt.Fprint(os.Stderr, err.Error())
 			os.Exit(err.Code())
@@ -63,22 +169,88 @@ func run(args []string) error {
 	default:
 		return fmt.Errorf("Unknown command: %s\n", command)
 	}
-
 	return nil
 }
 
-func repl() error {
-	interpreter := interpreter.NewInterpreter(os.Stdout)
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		fmt.Print("> ")
-		line, err := reader.ReadString('\n')
+// saveReports writes the profile of a run to profilePath and its coverage to
+// coverageDir, if they were asked for. The reports are written even if the
+// program failed. Failing to write one only fails the run when the program
+// itself succeeded.
+func saveReports(i *interpreter.Interpreter, profilePath, coverageDir, source string, succeeded bool) {
+	if source == "-" {
+		source = "<stdin>"
+	}
+	var err error
+	if profilePath != "" {
+		if writeErr := writeProfile(i, profilePath, source); writeErr != nil {
+			err = fmt.Errorf("Error writing profile: %w", writeErr)
+		}
+	}
+	if coverageDir != "" && err == nil {
+		if writeErr := writeCoverage(i, coverageDir, source); writeErr != nil {
+			err = fmt.Errorf("Error writing coverage: %w", writeErr)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if succeeded {
+			os.Exit(1)
+		}
+	}
+}
+
+func writeProfile(i *interpreter.Interpreter, path, source string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := i.WriteProfile(f, source); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeCoverage(i *interpreter.Interpreter, dir, source string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	reports := []struct {
+		name  string
+		write func(io.Writer, string) error
+	}{
+		{"lcov.info", i.WriteLCOV},
+		{"coverage.html", i.WriteCoverageHTML},
+	}
+	for _, report := range reports {
+		f, err := os.Create(filepath.Join(dir, report.name))
 		if err != nil {
 			return err
 		}
-		err = interpreter.Interpret(bytes.NewBuffer([]byte(line)))
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
+		if err := report.write(f, source); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathList is a flag that collects paths from comma-separated values and from
+// being repeated.
+type pathList []string
+
+func (p *pathList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pathList) Set(value string) error {
+	for _, path := range strings.Split(value, ",") {
+		if path != "" {
+			*p = append(*p, path)
 		}
 	}
+	return nil
 }
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/interpreter"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/lineedit"
+)
+
+const historyFileName = ".lox_history"
+
+const replHelp = `Commands:
+  :load <file>   execute a file in the current scope
+  :env           list the global bindings
+  :type <expr>   show the type of an expression's value
+  :ast <source>  show the parse tree of a statement or expression
+  :time <source> run a statement or expression and report how long it took
+  :reset         discard every binding
+  :help          show this message
+  :quit          exit the REPL`
+
+func repl(options ...interpreter.Option) error {
+	editor := lineedit.NewEditor(os.Stdin, os.Stdout)
+	// Programs read stdin through the editor's reader, so that neither
+	// buffers input meant for the other.
+	interpreter, err := interpreter.NewInterpreter(editor.Reader(), os.Stdout, options...)
+	if err != nil {
+		return err
+	}
+	editor.Complete = func(word string) []string {
+		return completions(interpreter, word)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if err := editor.LoadHistory(filepath.Join(home, historyFileName)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading history: %s\n", err)
+		}
+	}
+	input := strings.Builder{}
+	for {
+		prompt := "> "
+		if input.Len() > 0 {
+			prompt = "... "
+		}
+		line, err := editor.ReadLine(prompt)
+		if err == lineedit.ErrInterrupted {
+			input.Reset()
+			continue
+		}
+		if err == io.EOF {
+			if input.Len() > 0 {
+				evaluate(interpreter, input.String())
+			}
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if input.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if quit := runCommand(interpreter, strings.TrimSpace(line)); quit {
+				return nil
+			}
+			continue
+		}
+		input.WriteString(line + "\n")
+		if !isBalanced(input.String()) {
+			continue
+		}
+		evaluate(interpreter, input.String())
+		input.Reset()
+	}
+}
+
+// evaluate runs a complete REPL entry, echoing the value of a bare expression.
+func evaluate(interpreter *interpreter.Interpreter, source string) {
+	if strings.TrimSpace(source) == "" {
+		return
+	}
+	value, err := interpreter.Evaluate(strings.NewReader(source))
+	if err != nil {
+		reportError(err)
+	} else if value != nil {
+		fmt.Println(value)
+	}
+}
+
+// reportError prints an error from a REPL entry. If the entry called exit,
+// the REPL exits with the requested status instead.
+func reportError(err interpreter.InterpreterError) {
+	var exitErr *evaluator.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Status)
+	}
+	fmt.Fprintln(os.Stderr, err.Error())
+}
+
+// completions returns the keywords and global names that start with word.
+func completions(interpreter *interpreter.Interpreter, word string) []string {
+	if word == "" {
+		return nil
+	}
+	names, _ := interpreter.Globals()
+	candidates := make([]string, 0)
+	for _, name := range append(lexer.Keywords(), names...) {
+		if strings.HasPrefix(name, word) && !slices.Contains(candidates, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	slices.Sort(candidates)
+	return candidates
+}
+
+// runCommand executes a REPL meta-command such as ":load file.lox", reporting
+// whether the REPL should exit.
+func runCommand(interpreter *interpreter.Interpreter, line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":quit":
+		return true
+	case ":help":
+		fmt.Println(replHelp)
+	case ":reset":
+		interpreter.Reset()
+	case ":env":
+		names, values := interpreter.Globals()
+		for i, name := range names {
+			fmt.Printf("%s = %s\n", name, values[i])
+		}
+	case ":load":
+		file, err := os.Open(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening file: %s\n", err)
+			return false
+		}
+		defer file.Close()
+		if err := interpreter.Interpret(file); err != nil {
+			reportError(err)
+		}
+	case ":type":
+		value, err := interpreter.Evaluate(strings.NewReader(arg))
+		if err != nil {
+			reportError(err)
+			return false
+		}
+		if value == nil {
+			fmt.Fprintln(os.Stderr, "Expected an expression")
+			return false
+		}
+		fmt.Println(value.Type())
+	case ":ast":
+		statements, err := interpreter.Parse(strings.NewReader(arg))
+		if err != nil {
+			reportError(err)
+			return false
+		}
+		for _, statement := range statements {
+			fmt.Println(statement.String())
+		}
+	case ":time":
+		start := time.Now()
+		value, err := interpreter.Evaluate(strings.NewReader(arg))
+		elapsed := time.Since(start)
+		if err != nil {
+			reportError(err)
+		} else if value != nil {
+			fmt.Println(value)
+		}
+		fmt.Printf("Elapsed: %s\n", elapsed)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s (try :help)\n", name)
+	}
+	return false
+}
+
+// isBalanced reports whether every brace and parenthesis opened in the source
+// has been closed, meaning the REPL entry is ready to be interpreted. Brackets
+// inside strings and comments do not count. An entry with too many closing
+// brackets is ready too, so that its error is reported instead of waiting for
+// more input.
+func isBalanced(source string) bool {
+	tokens, _ := lexer.Tokenize(strings.NewReader(source))
+	depth := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case lexer.TokenTypeLeftParen, lexer.TokenTypeLeftBrace:
+			depth++
+		case lexer.TokenTypeRightParen, lexer.TokenTypeRightBrace:
+			depth--
+		}
+	}
+	return depth <= 0
+}
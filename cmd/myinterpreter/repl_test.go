@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestIsBalanced(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{"empty", "", true},
+		{"statement", "print 1;", true},
+		{"open brace", "fun f() {\n", false},
+		{"open parenthesis", "print (1 +\n", false},
+		{"closed block", "fun f() {\n  return 1;\n}\n", true},
+		{"nested", "if (a) {\n  while (b) {\n  }\n", false},
+		{"brace in a string", "print \"{\";", true},
+		{"closing brace in a string", "fun f() {\n  print \"}\";\n", false},
+		{"brace in a comment", "print 1; // {\n", true},
+		{"closing brace in a comment", "fun f() { // }\n", false},
+		{"unbalanced closing bracket", "print 1);\n", true},
+		{"closing bracket before opening", "} {\n", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := isBalanced(test.source); actual != test.expected {
+				t.Errorf("Expected isBalanced(%q) to be %t, got %t", test.source, test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package astjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/resolver"
+)
+
+// rawNode holds the fields of any statement or expression node. Which of
+// them are used depends on the node's kind.
+type rawNode struct {
+	Kind        string          `json:"kind"`
+	Name        string          `json:"name"`
+	Operator    string          `json:"operator"`
+	Params      []string        `json:"params"`
+	Value       json.RawMessage `json:"value"`
+	Expression  *rawNode        `json:"expression"`
+	Initializer *rawNode        `json:"initializer"`
+	Statements  []*rawNode      `json:"statements"`
+	Condition   *rawNode        `json:"condition"`
+	Then        *rawNode        `json:"then"`
+	Else        *rawNode        `json:"else"`
+	Body        *rawNode        `json:"body"`
+	Child       *rawNode        `json:"child"`
+	Left        *rawNode        `json:"left"`
+	Right       *rawNode        `json:"right"`
+	Callee      *rawNode        `json:"callee"`
+	Arguments   []*rawNode      `json:"arguments"`
+	Object      *rawNode        `json:"object"`
+	Line        int             `json:"line"`
+}
+
+type rawProgram struct {
+	Version    int        `json:"version"`
+	Statements []*rawNode `json:"statements"`
+}
+
+// Unmarshal decodes a document produced by Marshal back into statements that
+// can be executed, resolving their variables as the parser would.
+func Unmarshal(data []byte) ([]evaluator.Statement, *DecodeError) {
+	var prog rawProgram
+	if err := json.Unmarshal(data, &prog); err != nil {
+		return nil, NewDecodeError(err.Error())
+	}
+	if prog.Version != Version {
+		return nil, NewDecodeError(fmt.Sprintf("Unsupported version %d, expected %d", prog.Version, Version))
+	}
+	statements, err := decodeStatements(prog.Statements)
+	if err != nil {
+		return nil, err
+	}
+	resolver.Resolve(statements)
+	return statements, nil
+}
+
+func decodeStatements(nodes []*rawNode) ([]evaluator.Statement, *DecodeError) {
+	statements := make([]evaluator.Statement, 0, len(nodes))
+	for _, node := range nodes {
+		statement, err := decodeStatement(node)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+func decodeBlock(node *rawNode) (*evaluator.BlockStatement, *DecodeError) {
+	if node == nil {
+		return nil, NewDecodeError("Expected block")
+	}
+	if node.Kind != "Block" {
+		return nil, NewDecodeError(fmt.Sprintf("Expected Block, got %q", node.Kind))
+	}
+	statements, err := decodeStatements(node.Statements)
+	if err != nil {
+		return nil, err
+	}
+	return &evaluator.BlockStatement{Statements: statements}, nil
+}
+
+func decodeStatement(node *rawNode) (evaluator.Statement, *DecodeError) {
+	if node == nil {
+		return nil, NewDecodeError("Expected statement, got null")
+	}
+	switch node.Kind {
+	case "Expression":
+		expr, err := decodeExpression(node.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.ExpressionStatement{Expression: expr, Line: node.Line}, nil
+	case "Print":
+		expr, err := decodeExpression(node.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.PrintStatement{Expression: expr, Line: node.Line}, nil
+	case "Var":
+		if node.Name == "" {
+			return nil, NewDecodeError("Var is missing its name")
+		}
+		varStmt := &evaluator.VarStatement{Name: node.Name, Line: node.Line}
+		if node.Initializer != nil {
+			init, err := decodeExpression(node.Initializer)
+			if err != nil {
+				return nil, err
+			}
+			varStmt.Expr = init
+		}
+		return varStmt, nil
+	case "Block":
+		return decodeBlock(node)
+	case "If":
+		condition, err := decodeExpression(node.Condition)
+		if err != nil {
+			return nil, err
+		}
+		then, err := decodeBlock(node.Then)
+		if err != nil {
+			return nil, err
+		}
+		ifStmt := &evaluator.IfStatement{Condition: condition, Then: then, Line: node.Line}
+		if node.Else != nil {
+			ifStmt.Else, err = decodeBlock(node.Else)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ifStmt, nil
+	case "While":
+		condition, err := decodeExpression(node.Condition)
+		if err != nil {
+			return nil, err
+		}
+		body, err := decodeBlock(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.WhileStatement{Condition: condition, Body: body, Line: node.Line}, nil
+	case "Fun":
+		if node.Name == "" {
+			return nil, NewDecodeError("Fun is missing its name")
+		}
+		body, err := decodeBlock(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		params := node.Params
+		if params == nil {
+			params = make([]string, 0)
+		}
+		return &evaluator.FunStatement{Name: node.Name, Params: params, Body: body, Line: node.Line}, nil
+	case "Return":
+		returnStmt := &evaluator.ReturnStatement{Expr: &evaluator.ExpressionLiteral{Literal: nil}, Line: node.Line}
+		if len(node.Value) > 0 && string(node.Value) != "null" {
+			var value rawNode
+			if err := json.Unmarshal(node.Value, &value); err != nil {
+				return nil, NewDecodeError(err.Error())
+			}
+			expr, err := decodeExpression(&value)
+			if err != nil {
+				return nil, err
+			}
+			returnStmt.Expr = expr
+		}
+		return returnStmt, nil
+	}
+	return nil, NewDecodeError(fmt.Sprintf("Unknown statement kind %q", node.Kind))
+}
+
+func decodeExpressions(nodes []*rawNode) ([]evaluator.Expression, *DecodeError) {
+	expressions := make([]evaluator.Expression, 0, len(nodes))
+	for _, node := range nodes {
+		expression, err := decodeExpression(node)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+	}
+	return expressions, nil
+}
+
+func decodeExpression(node *rawNode) (evaluator.Expression, *DecodeError) {
+	if node == nil {
+		return nil, NewDecodeError("Expected expression, got null")
+	}
+	switch node.Kind {
+	case "Literal":
+		if len(node.Value) == 0 {
+			return nil, NewDecodeError("Literal is missing its value")
+		}
+		var value any
+		if err := json.Unmarshal(node.Value, &value); err != nil {
+			return nil, NewDecodeError(err.Error())
+		}
+		switch value.(type) {
+		case nil, float64, string, bool:
+			return &evaluator.ExpressionLiteral{Literal: value}, nil
+		}
+		return nil, NewDecodeError(fmt.Sprintf("Literal value must be a number, string, bool or null, got %s", string(node.Value)))
+	case "Group":
+		child, err := decodeExpression(node.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.ExpressionGroup{Child: child}, nil
+	case "Unary":
+		operator, err := decodeUnaryOperator(node.Operator)
+		if err != nil {
+			return nil, err
+		}
+		child, err := decodeExpression(node.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.ExpressionUnary{Operator: operator, Child: child}, nil
+	case "Binary":
+		operator, err := decodeBinaryOperator(node.Operator)
+		if err != nil {
+			return nil, err
+		}
+		left, err := decodeExpression(node.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := decodeExpression(node.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.ExpressionBinary{Operator: operator, Left: left, Right: right}, nil
+	case "Variable":
+		if node.Name == "" {
+			return nil, NewDecodeError("Variable is missing its name")
+		}
+		return &evaluator.ExpressionVariable{Name: node.Name}, nil
+	case "Assignment":
+		if node.Name == "" {
+			return nil, NewDecodeError("Assignment is missing its name")
+		}
+		var value *rawNode
+		if err := json.Unmarshal(node.Value, &value); err != nil {
+			return nil, NewDecodeError("Assignment is missing its value")
+		}
+		expr, err := decodeExpression(value)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.ExpressionAssignment{Name: node.Name, Expr: expr}, nil
+	case "Call":
+		callee, err := decodeExpression(node.Callee)
+		if err != nil {
+			return nil, err
+		}
+		args, err := decodeExpressions(node.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.ExpressionCall{Callee: callee, Args: args}, nil
+	case "Get":
+		if node.Name == "" {
+			return nil, NewDecodeError("Get is missing its name")
+		}
+		object, err := decodeExpression(node.Object)
+		if err != nil {
+			return nil, err
+		}
+		return &evaluator.ExpressionGet{Object: object, Name: node.Name}, nil
+	}
+	return nil, NewDecodeError(fmt.Sprintf("Unknown expression kind %q", node.Kind))
+}
+
+func decodeUnaryOperator(op string) (evaluator.UnaryOperator, *DecodeError) {
+	for _, operator := range []evaluator.UnaryOperator{evaluator.UnaryOperatorBang, evaluator.UnaryOperatorMinus} {
+		if operator.String() == op {
+			return operator, nil
+		}
+	}
+	return 0, NewDecodeError(fmt.Sprintf("Unknown unary operator %q", op))
+}
+
+func decodeBinaryOperator(op string) (evaluator.BinaryOperator, *DecodeError) {
+	for operator := evaluator.BinaryOperatorMultiply; operator <= evaluator.BinaryOperatorOr; operator++ {
+		if operator.String() == op {
+			return operator, nil
+		}
+	}
+	return 0, NewDecodeError(fmt.Sprintf("Unknown binary operator %q", op))
+}
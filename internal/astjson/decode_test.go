@@ -0,0 +1,103 @@
+package astjson
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+func TestRoundTrip(t *testing.T) {
+	programs := []string{
+		"print \"hello\" + \" world\";",
+		"var a; var b = nil; a = b = !true;",
+		"{ var a = 1; { print -a * (2 - 3) / 4; } }",
+		"if (a >= 1 or b < 2 and c != d) { print 1; } else { print 2; }",
+		"for (var i = 0; i <= 10; i = i + 1) { print i == 3; }",
+		"fun f(n) {\n  if (n > 0) {\n    return f(n - 1);\n  }\n}\nf(3);",
+		"fun add(a, b) { return a + b; } fun noop() { return; } print add(1)(2) > noop();",
+		"print math.sqrt(math.pi);",
+	}
+	for _, program := range programs {
+		t.Run(program, func(t *testing.T) {
+			tokens, _ := lexer.Tokenize(bytes.NewBufferString(program))
+			statements, parserErr := parser.Parse(tokens)
+			if parserErr != nil {
+				t.Fatalf("Expected no parser error, got %v", parserErr)
+			}
+			encoded, err := Marshal(statements)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			decoded, decodeErr := Unmarshal(encoded)
+			if decodeErr != nil {
+				t.Fatalf("Expected no error, got %v", decodeErr)
+			}
+			if len(decoded) != len(statements) {
+				t.Fatalf("Expected %d statements, got %d", len(statements), len(decoded))
+			}
+			for i, statement := range statements {
+				if decoded[i].String() != statement.String() {
+					t.Errorf("Expected %s, got %s", statement.String(), decoded[i].String())
+				}
+			}
+			// Re-encoding also checks fields that String leaves out, such as
+			// lines.
+			reencoded, err := Marshal(decoded)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !bytes.Equal(reencoded, encoded) {
+				t.Errorf("Expected %s, got %s", encoded, reencoded)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{
+			name:     "invalid json",
+			document: `{"version": 1, "statements": [`,
+		},
+		{
+			name:     "missing version",
+			document: `{"statements": []}`,
+		},
+		{
+			name:     "unsupported version",
+			document: `{"version": 99, "statements": []}`,
+		},
+		{
+			name:     "unknown statement kind",
+			document: `{"version": 1, "statements": [{"kind": "Loop"}]}`,
+		},
+		{
+			name:     "unknown operator",
+			document: `{"version": 1, "statements": [{"kind": "Expression", "expression": {"kind": "Binary", "operator": "%", "left": {"kind": "Literal", "value": 1}, "right": {"kind": "Literal", "value": 2}}}]}`,
+		},
+		{
+			name:     "missing operand",
+			document: `{"version": 1, "statements": [{"kind": "Print", "expression": {"kind": "Unary", "operator": "-"}}]}`,
+		},
+		{
+			name:     "non-scalar literal",
+			document: `{"version": 1, "statements": [{"kind": "Print", "expression": {"kind": "Literal", "value": [1]}}]}`,
+		},
+		{
+			name:     "statement where block expected",
+			document: `{"version": 1, "statements": [{"kind": "While", "condition": {"kind": "Literal", "value": true}, "body": {"kind": "Print", "expression": {"kind": "Literal", "value": 1}}}]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Unmarshal([]byte(test.document)); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package astjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Version identifies the schema of encoded programs. It is incremented
+// whenever a change would stop older documents from decoding correctly.
+const Version = 1
+
+type program struct {
+	Version    int   `json:"version"`
+	Statements []any `json:"statements"`
+}
+
+type expressionStatementNode struct {
+	Kind       string `json:"kind"`
+	Expression any    `json:"expression"`
+	Line       int    `json:"line,omitempty"`
+}
+
+type printNode struct {
+	Kind       string `json:"kind"`
+	Expression any    `json:"expression"`
+	Line       int    `json:"line,omitempty"`
+}
+
+type varNode struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Initializer any    `json:"initializer"`
+	Line        int    `json:"line,omitempty"`
+}
+
+type blockNode struct {
+	Kind       string `json:"kind"`
+	Statements []any  `json:"statements"`
+}
+
+type ifNode struct {
+	Kind      string `json:"kind"`
+	Condition any    `json:"condition"`
+	Then      any    `json:"then"`
+	Else      any    `json:"else"`
+	Line      int    `json:"line,omitempty"`
+}
+
+type whileNode struct {
+	Kind      string `json:"kind"`
+	Condition any    `json:"condition"`
+	Body      any    `json:"body"`
+	Line      int    `json:"line,omitempty"`
+}
+
+type funNode struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Params []string `json:"params"`
+	Body   any      `json:"body"`
+	Line   int      `json:"line,omitempty"`
+}
+
+type returnNode struct {
+	Kind  string `json:"kind"`
+	Value any    `json:"value"`
+	Line  int    `json:"line,omitempty"`
+}
+
+type literalNode struct {
+	Kind  string `json:"kind"`
+	Value any    `json:"value"`
+}
+
+type groupNode struct {
+	Kind  string `json:"kind"`
+	Child any    `json:"child"`
+}
+
+type unaryNode struct {
+	Kind     string `json:"kind"`
+	Operator string `json:"operator"`
+	Child    any    `json:"child"`
+}
+
+type binaryNode struct {
+	Kind     string `json:"kind"`
+	Operator string `json:"operator"`
+	Left     any    `json:"left"`
+	Right    any    `json:"right"`
+}
+
+type variableNode struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type assignmentNode struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+type callNode struct {
+	Kind      string `json:"kind"`
+	Callee    any    `json:"callee"`
+	Arguments []any  `json:"arguments"`
+}
+
+type getNode struct {
+	Kind   string `json:"kind"`
+	Object any    `json:"object"`
+	Name   string `json:"name"`
+}
+
+// Marshal encodes a parsed program as a versioned JSON document with one
+// object per node, each tagged with its "kind".
+func Marshal(statements []evaluator.Statement) ([]byte, error) {
+	nodes, err := encodeStatements(statements)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(program{Version: Version, Statements: nodes}, "", "  ")
+}
+
+func encodeStatements(statements []evaluator.Statement) ([]any, error) {
+	nodes := make([]any, 0, len(statements))
+	for _, statement := range statements {
+		node, err := encodeStatement(statement)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func encodeBlock(block *evaluator.BlockStatement) (any, error) {
+	if block == nil {
+		return nil, nil
+	}
+	return encodeStatement(block)
+}
+
+func encodeStatement(statement evaluator.Statement) (any, error) {
+	switch s := statement.(type) {
+	case *evaluator.ExpressionStatement:
+		expr, err := encodeExpression(s.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return expressionStatementNode{Kind: "Expression", Expression: expr, Line: s.Line}, nil
+	case *evaluator.PrintStatement:
+		expr, err := encodeExpression(s.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return printNode{Kind: "Print", Expression: expr, Line: s.Line}, nil
+	case *evaluator.VarStatement:
+		init, err := encodeExpression(s.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return varNode{Kind: "Var", Name: s.Name, Initializer: init, Line: s.Line}, nil
+	case *evaluator.BlockStatement:
+		statements, err := encodeStatements(s.Statements)
+		if err != nil {
+			return nil, err
+		}
+		return blockNode{Kind: "Block", Statements: statements}, nil
+	case *evaluator.IfStatement:
+		condition, err := encodeExpression(s.Condition)
+		if err != nil {
+			return nil, err
+		}
+		then, err := encodeBlock(s.Then)
+		if err != nil {
+			return nil, err
+		}
+		elseBlock, err := encodeBlock(s.Else)
+		if err != nil {
+			return nil, err
+		}
+		return ifNode{Kind: "If", Condition: condition, Then: then, Else: elseBlock, Line: s.Line}, nil
+	case *evaluator.WhileStatement:
+		condition, err := encodeExpression(s.Condition)
+		if err != nil {
+			return nil, err
+		}
+		body, err := encodeBlock(s.Body)
+		if err != nil {
+			return nil, err
+		}
+		return whileNode{Kind: "While", Condition: condition, Body: body, Line: s.Line}, nil
+	case *evaluator.FunStatement:
+		body, err := encodeBlock(s.Body)
+		if err != nil {
+			return nil, err
+		}
+		return funNode{Kind: "Fun", Name: s.Name, Params: s.Params, Body: body, Line: s.Line}, nil
+	case *evaluator.ReturnStatement:
+		value, err := encodeExpression(s.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return returnNode{Kind: "Return", Value: value, Line: s.Line}, nil
+	}
+	return nil, fmt.Errorf("Unknown statement type %T", statement)
+}
+
+func encodeExpressions(expressions []evaluator.Expression) ([]any, error) {
+	nodes := make([]any, 0, len(expressions))
+	for _, expression := range expressions {
+		node, err := encodeExpression(expression)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func encodeExpression(expression evaluator.Expression) (any, error) {
+	switch e := expression.(type) {
+	case nil:
+		return nil, nil
+	case *evaluator.ExpressionLiteral:
+		return literalNode{Kind: "Literal", Value: e.Literal}, nil
+	case *evaluator.ExpressionGroup:
+		child, err := encodeExpression(e.Child)
+		if err != nil {
+			return nil, err
+		}
+		return groupNode{Kind: "Group", Child: child}, nil
+	case *evaluator.ExpressionUnary:
+		child, err := encodeExpression(e.Child)
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{Kind: "Unary", Operator: e.Operator.String(), Child: child}, nil
+	case *evaluator.ExpressionBinary:
+		left, err := encodeExpression(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := encodeExpression(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{Kind: "Binary", Operator: e.Operator.String(), Left: left, Right: right}, nil
+	case *evaluator.ExpressionVariable:
+		return variableNode{Kind: "Variable", Name: e.Name}, nil
+	case *evaluator.ExpressionAssignment:
+		value, err := encodeExpression(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return assignmentNode{Kind: "Assignment", Name: e.Name, Value: value}, nil
+	case *evaluator.ExpressionCall:
+		callee, err := encodeExpression(e.Callee)
+		if err != nil {
+			return nil, err
+		}
+		args, err := encodeExpressions(e.Args)
+		if err != nil {
+			return nil, err
+		}
+		return callNode{Kind: "Call", Callee: callee, Arguments: args}, nil
+	case *evaluator.ExpressionGet:
+		object, err := encodeExpression(e.Object)
+		if err != nil {
+			return nil, err
+		}
+		return getNode{Kind: "Get", Object: object, Name: e.Name}, nil
+	}
+	return nil, fmt.Errorf("Unknown expression type %T", expression)
+}
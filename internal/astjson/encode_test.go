@@ -0,0 +1,73 @@
+package astjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  string
+		expected string
+	}{
+		{
+			name:     "print literal",
+			program:  "print \"hi\";",
+			expected: `{"version":1,"statements":[{"kind":"Print","expression":{"kind":"Literal","value":"hi"},"line":1}]}`,
+		},
+		{
+			name:     "var without initializer",
+			program:  "var a;",
+			expected: `{"version":1,"statements":[{"kind":"Var","name":"a","initializer":null,"line":1}]}`,
+		},
+		{
+			name:    "expressions",
+			program: "a = -(1 + b) and f(nil, true);",
+			expected: `{"version":1,"statements":[{"kind":"Expression","expression":{"kind":"Assignment","name":"a","value":` +
+				`{"kind":"Binary","operator":"and",` +
+				`"left":{"kind":"Unary","operator":"-","child":{"kind":"Group","child":` +
+				`{"kind":"Binary","operator":"+","left":{"kind":"Literal","value":1},"right":{"kind":"Variable","name":"b"}}}},` +
+				`"right":{"kind":"Call","callee":{"kind":"Variable","name":"f"},` +
+				`"arguments":[{"kind":"Literal","value":null},{"kind":"Literal","value":true}]}}},"line":1}]}`,
+		},
+		{
+			name:    "if and while",
+			program: "if (a) {} else {\n  while (b) {}\n}",
+			expected: `{"version":1,"statements":[{"kind":"If","condition":{"kind":"Variable","name":"a"},` +
+				`"then":{"kind":"Block","statements":[]},"else":{"kind":"Block","statements":[` +
+				`{"kind":"While","condition":{"kind":"Variable","name":"b"},"body":{"kind":"Block","statements":[]},"line":2}]},"line":1}]}`,
+		},
+		{
+			name:    "function",
+			program: "fun id(x) { return x; }",
+			expected: `{"version":1,"statements":[{"kind":"Fun","name":"id","params":["x"],"body":{"kind":"Block","statements":[` +
+				`{"kind":"Return","value":{"kind":"Variable","name":"x"},"line":1}]},"line":1}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, _ := lexer.Tokenize(bytes.NewBufferString(test.program))
+			statements, err := parser.Parse(tokens)
+			if err != nil {
+				t.Fatalf("Expected no parser error, got %v", err)
+			}
+			encoded, encodeErr := Marshal(statements)
+			if encodeErr != nil {
+				t.Fatalf("Expected no error, got %v", encodeErr)
+			}
+			compact := bytes.NewBuffer(nil)
+			if err := json.Compact(compact, encoded); err != nil {
+				t.Fatalf("Expected valid JSON, got %v", err)
+			}
+			if compact.String() != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, compact.String())
+			}
+		})
+	}
+}
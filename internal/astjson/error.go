@@ -0,0 +1,22 @@
+package astjson
+
+import (
+	"errors"
+	"fmt"
+)
+
+type DecodeError struct {
+	err error
+}
+
+func NewDecodeError(msg string) *DecodeError {
+	return &DecodeError{err: errors.New(msg)}
+}
+
+func (e *DecodeError) Code() int {
+	return 65
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("AST Error: %s", e.err.Error())
+}
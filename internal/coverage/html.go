@@ -0,0 +1,121 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+var page = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage of {{.Filename}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; font-family: monospace; }
+td { padding: 0 0.5em; white-space: pre; vertical-align: top; }
+td.number, td.count { color: #666; text-align: right; }
+tr.covered td.code { background: #d7f5d7; }
+tr.uncovered td.code { background: #f8d4d4; }
+tr.partial td.code { background: #f8efc4; }
+</style>
+</head>
+<body>
+<h1>{{.Filename}}</h1>
+<p>Lines: {{.Lines}}. Branches: {{.Branches}}.</p>
+<table>
+{{range .Source}}<tr class="{{.Class}}"{{with .Note}} title="{{.}}"{{end}}><td class="number">{{.Number}}</td><td class="count">{{.Count}}</td><td class="code">{{.Code}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type pageData struct {
+	Filename string
+	Lines    string
+	Branches string
+	Source   []sourceLine
+}
+
+type sourceLine struct {
+	Number int
+	Count  string
+	Code   string
+	Class  string
+	Note   string
+}
+
+// WriteHTML writes c to w as a page showing source, the text of the file
+// filename, with each line marked by whether it ran and how many times.
+// Lines with an if or while statement that did not go both ways are marked
+// as partly covered.
+func WriteHTML(w io.Writer, c *evaluator.Coverage, filename string, source []byte) error {
+	lines := strings.Split(strings.TrimSuffix(string(source), "\n"), "\n")
+	data := pageData{Filename: filename, Source: make([]sourceLine, len(lines))}
+	for i, code := range lines {
+		data.Source[i] = sourceLine{Number: i + 1, Code: code}
+	}
+	line := func(number int) *sourceLine {
+		for len(data.Source) < number {
+			data.Source = append(data.Source, sourceLine{Number: len(data.Source) + 1})
+		}
+		return &data.Source[number-1]
+	}
+
+	found, hit := 0, 0
+	for _, count := range c.Lines() {
+		l := line(count.Line)
+		l.Count = fmt.Sprintf("%d×", count.Count)
+		l.Class = "uncovered"
+		found++
+		if count.Count > 0 {
+			l.Class = "covered"
+			hit++
+		}
+	}
+	data.Lines = summary(hit, found)
+
+	found, hit = 0, 0
+	for _, branch := range c.Branches() {
+		for _, taken := range branch.Taken {
+			found++
+			if taken > 0 {
+				hit++
+			}
+		}
+		l := line(branch.Line)
+		if note := branchNote(branch); note != "" && branch.Count > 0 {
+			l.Class = "partial"
+			l.Note = strings.TrimPrefix(l.Note+"; "+note, "; ")
+		}
+	}
+	data.Branches = summary(hit, found)
+
+	return page.Execute(w, data)
+}
+
+// branchNote describes the way an if or while statement never went, if any.
+func branchNote(branch evaluator.BranchCount) string {
+	switch {
+	case branch.IsLoop && branch.Taken[0] == 0:
+		return "loop body never ran"
+	case branch.IsLoop && branch.Taken[1] == 0:
+		return "loop never exited"
+	case branch.Taken[0] == 0:
+		return "condition never true"
+	case branch.Taken[1] == 0:
+		return "condition never false"
+	}
+	return ""
+}
+
+func summary(hit, found int) string {
+	if found == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d of %d (%.1f%%)", hit, found, 100*float64(hit)/float64(found))
+}
@@ -0,0 +1,32 @@
+package coverage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	program := "var s = \"<b>\";\nif (s == \"\") {\n  print s;\n}\nvar i = 0;\nwhile (true) { i = i + 1; if (i > 2) { print 1 + nil; } }\n"
+	out := bytes.Buffer{}
+	if err := WriteHTML(&out, run(t, program), "test.lox", []byte(program)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	page := out.String()
+	for _, expected := range []string{
+		"<title>Coverage of test.lox</title>",
+		"Lines: 4 of 5 (80.0%). Branches: 4 of 6 (66.7%).",
+		`<tr class="covered"><td class="number">1</td><td class="count">1×</td><td class="code">var s = &#34;&lt;b&gt;&#34;;</td></tr>`,
+		`<tr class="partial" title="condition never true"><td class="number">2</td>`,
+		`<tr class="uncovered"><td class="number">3</td><td class="count">0×</td>`,
+		`<tr class=""><td class="number">4</td><td class="count"></td><td class="code">}</td></tr>`,
+		`<tr class="partial" title="loop never exited"><td class="number">6</td><td class="count">3×</td>`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the page to contain %s, got\n%s", expected, page)
+		}
+	}
+	if strings.Contains(page, `<td class="number">7</td>`) {
+		t.Errorf("Expected no row for the empty line after the final newline")
+	}
+}
@@ -0,0 +1,58 @@
+// Package coverage writes the statement and branch counts of a Lox program
+// run as an LCOV report, which tools such as genhtml and most CI services
+// read, and as an HTML view of the source.
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// WriteLCOV writes c to w as an LCOV tracefile for the source file filename.
+// Each if and while statement has two branches. A branch of a statement that
+// never ran is reported as not taken with "-", as LCOV expects.
+func WriteLCOV(w io.Writer, c *evaluator.Coverage, filename string) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "TN:")
+	fmt.Fprintf(out, "SF:%s\n", filename)
+
+	found, hit := 0, 0
+	block, lastLine := 0, 0
+	for _, branch := range c.Branches() {
+		if branch.Line == lastLine {
+			block++
+		} else {
+			block, lastLine = 0, branch.Line
+		}
+		for i, taken := range branch.Taken {
+			count := "-"
+			if branch.Count > 0 {
+				count = strconv.Itoa(taken)
+			}
+			fmt.Fprintf(out, "BRDA:%d,%d,%d,%s\n", branch.Line, block, i, count)
+			found++
+			if taken > 0 {
+				hit++
+			}
+		}
+	}
+	fmt.Fprintf(out, "BRF:%d\n", found)
+	fmt.Fprintf(out, "BRH:%d\n", hit)
+
+	found, hit = 0, 0
+	for _, line := range c.Lines() {
+		fmt.Fprintf(out, "DA:%d,%d\n", line.Line, line.Count)
+		found++
+		if line.Count > 0 {
+			hit++
+		}
+	}
+	fmt.Fprintf(out, "LF:%d\n", found)
+	fmt.Fprintf(out, "LH:%d\n", hit)
+	fmt.Fprintln(out, "end_of_record")
+	return out.Flush()
+}
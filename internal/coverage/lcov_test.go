@@ -0,0 +1,71 @@
+package coverage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+// run runs a program and returns its coverage, ignoring runtime errors.
+func run(t *testing.T, program string) *evaluator.Coverage {
+	t.Helper()
+	tokens, _ := lexer.Tokenize(bytes.NewBufferString(program))
+	statements, parserErr := parser.Parse(tokens)
+	if parserErr != nil {
+		t.Fatalf("Expected no parser error, got %v", parserErr)
+	}
+	coverage := evaluator.NewCoverage()
+	coverage.Add(statements)
+	env := evaluator.NewEnvironment()
+	env.SetCoverage(coverage)
+	for _, statement := range statements {
+		if err := statement.Execute(env, bytes.NewBuffer(nil)); err != nil {
+			break
+		}
+	}
+	return coverage
+}
+
+func TestWriteLCOV(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  string
+		expected string
+	}{
+		{
+			name:    "statements",
+			program: "print 1;\nprint 2;",
+			expected: "TN:\nSF:test.lox\nBRF:0\nBRH:0\n" +
+				"DA:1,1\nDA:2,1\nLF:2\nLH:2\nend_of_record\n",
+		},
+		{
+			name:    "branches",
+			program: "fun f(n) {\n  if (n > 0) { print n; }\n}\nf(1); f(2);\nvar i = 0;\nwhile (i < 2) { i = i + 1; }",
+			expected: "TN:\nSF:test.lox\n" +
+				"BRDA:2,0,0,2\nBRDA:2,0,1,0\nBRDA:6,0,0,2\nBRDA:6,0,1,1\nBRF:4\nBRH:3\n" +
+				"DA:1,1\nDA:2,2\nDA:4,1\nDA:5,1\nDA:6,2\nLF:5\nLH:5\nend_of_record\n",
+		},
+		{
+			name:    "statements that never ran",
+			program: "fun f() {\n  if (true) { if (false) {} }\n}",
+			expected: "TN:\nSF:test.lox\n" +
+				"BRDA:2,0,0,-\nBRDA:2,0,1,-\nBRDA:2,1,0,-\nBRDA:2,1,1,-\nBRF:4\nBRH:0\n" +
+				"DA:1,1\nDA:2,0\nLF:2\nLH:1\nend_of_record\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := bytes.Buffer{}
+			if err := WriteLCOV(&out, run(t, test.program), "test.lox"); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if out.String() != test.expected {
+				t.Errorf("Expected\n%s\ngot\n%s", test.expected, out.String())
+			}
+		})
+	}
+}
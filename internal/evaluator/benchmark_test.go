@@ -0,0 +1,90 @@
+package evaluator_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+func benchmarkProgram(b *testing.B, program string) {
+	b.Helper()
+	tokens, lexerErr := lexer.Tokenize(bytes.NewBufferString(program))
+	if lexerErr != nil {
+		b.Fatalf("Expected no lexer error, got %v", lexerErr)
+	}
+	statements, parserErr := parser.Parse(tokens)
+	if parserErr != nil {
+		b.Fatalf("Expected no parser error, got %v", parserErr)
+	}
+	b.ResetTimer()
+	for range b.N {
+		env := evaluator.NewEnvironment()
+		for _, statement := range statements {
+			if err := statement.Execute(env, io.Discard); err != nil {
+				b.Fatalf("Expected no error, got %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	benchmarkProgram(b, `
+fun fib(n) {
+	if (n < 2) { return n; }
+	return fib(n - 1) + fib(n - 2);
+}
+print fib(20);
+`)
+}
+
+func BenchmarkLoop(b *testing.B) {
+	benchmarkProgram(b, `
+fun sum(n) {
+	var total = 0;
+	for (var i = 0; i < n; i = i + 1) {
+		var square = i * i;
+		total = total + square;
+	}
+	return total;
+}
+print sum(100000);
+`)
+}
+
+func BenchmarkNestedScopes(b *testing.B) {
+	benchmarkProgram(b, `
+fun outer() {
+	var a = 1;
+	fun inner() {
+		var count = 0;
+		while (count < 10000) {
+			{ { count = count + a; } }
+		}
+		return count;
+	}
+	return inner();
+}
+print outer();
+`)
+}
+
+func BenchmarkArithmetic(b *testing.B) {
+	tokens, _ := lexer.Tokenize(bytes.NewBufferString("(1 + 2) * 3 - 4 / 2 < 10 == !false;"))
+	statements, parserErr := parser.Parse(tokens)
+	if parserErr != nil {
+		b.Fatalf("Expected no parser error, got %v", parserErr)
+	}
+	expr := statements[0].(*evaluator.ExpressionStatement).Expression
+	env := evaluator.NewEnvironment()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := expr.Evaluate(env, io.Discard); err != nil {
+			b.Fatalf("Expected no error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package evaluator
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Coverage counts how many times each statement of a program runs, and which
+// way each if and while statement goes, for coverage reports.
+type Coverage struct {
+	counts   map[Statement]int
+	branches map[Statement]*[2]int
+	order    []Statement
+}
+
+// LineCount is the number of times the statements on a line ran. A line with
+// several statements on it gets the highest count among them.
+type LineCount struct {
+	Line  int
+	Count int
+}
+
+// BranchCount is the number of times an if or while statement ran, and the
+// number of times it went each way. The first branch is the then block of an
+// if statement or the body of a while statement. The second is the else
+// block, which is taken even if the statement has none, or the exit from the
+// loop.
+type BranchCount struct {
+	Line   int
+	Count  int
+	Taken  [2]int
+	IsLoop bool
+}
+
+func NewCoverage() *Coverage {
+	return &Coverage{counts: make(map[Statement]int), branches: make(map[Statement]*[2]int)}
+}
+
+// SetCoverage counts the statements the program runs in c. A nil coverage
+// turns counting off.
+func (e *Environment) SetCoverage(c *Coverage) {
+	e.globals.coverage = c
+}
+
+// cover counts a run of a statement.
+func (e *Environment) cover(stmt Statement) {
+	if c := e.globals.coverage; c != nil {
+		c.counts[stmt]++
+	}
+}
+
+// branch counts an if or while statement going the given way, 0 for the
+// first branch and 1 for the second.
+func (e *Environment) branch(stmt Statement, taken int) {
+	if c := e.globals.coverage; c != nil {
+		c.taken(stmt)[taken]++
+	}
+}
+
+func (c *Coverage) taken(stmt Statement) *[2]int {
+	taken, ok := c.branches[stmt]
+	if !ok {
+		taken = &[2]int{}
+		c.branches[stmt] = taken
+		c.order = append(c.order, stmt)
+	}
+	return taken
+}
+
+// Add registers the statements of a program, along with the statements
+// nested in them, so that those that never run are counted as well.
+func (c *Coverage) Add(statements []Statement) {
+	for _, stmt := range statements {
+		c.add(stmt)
+	}
+}
+
+func (c *Coverage) add(stmt Statement) {
+	if block, ok := stmt.(*BlockStatement); ok {
+		c.Add(block.Statements)
+		return
+	}
+	if _, ok := c.counts[stmt]; !ok {
+		c.counts[stmt] = 0
+	}
+	switch s := stmt.(type) {
+	case *IfStatement:
+		c.taken(s)
+		c.Add(s.Then.Statements)
+		if s.Else != nil {
+			c.Add(s.Else.Statements)
+		}
+	case *WhileStatement:
+		c.taken(s)
+		c.Add(s.Body.Statements)
+	case *FunStatement:
+		c.Add(s.Body.Statements)
+	}
+}
+
+// Lines returns the counts for every line with a statement on it, in order.
+func (c *Coverage) Lines() []LineCount {
+	byLine := make(map[int]int)
+	for stmt, count := range c.counts {
+		line := statementLine(stmt)
+		if line == 0 {
+			continue
+		}
+		if current, ok := byLine[line]; !ok || count > current {
+			byLine[line] = count
+		}
+	}
+	lines := make([]LineCount, 0, len(byLine))
+	for line, count := range byLine {
+		lines = append(lines, LineCount{Line: line, Count: count})
+	}
+	slices.SortFunc(lines, func(a, b LineCount) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+	return lines
+}
+
+// Branches returns the counts for every if and while statement, in order of
+// their lines.
+func (c *Coverage) Branches() []BranchCount {
+	branches := make([]BranchCount, 0, len(c.order))
+	for _, stmt := range c.order {
+		line := statementLine(stmt)
+		if line == 0 {
+			continue
+		}
+		_, isLoop := stmt.(*WhileStatement)
+		branches = append(branches, BranchCount{Line: line, Count: c.counts[stmt], Taken: *c.branches[stmt], IsLoop: isLoop})
+	}
+	slices.SortStableFunc(branches, func(a, b BranchCount) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+	return branches
+}
+
+func statementLine(stmt Statement) int {
+	switch s := stmt.(type) {
+	case *ExpressionStatement:
+		return s.Line
+	case *PrintStatement:
+		return s.Line
+	case *VarStatement:
+		return s.Line
+	case *IfStatement:
+		return s.Line
+	case *WhileStatement:
+		return s.Line
+	case *FunStatement:
+		return s.Line
+	case *ReturnStatement:
+		return s.Line
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package evaluator_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		name             string
+		program          string
+		expectedLines    []evaluator.LineCount
+		expectedBranches []evaluator.BranchCount
+	}{
+		{
+			name:          "straight line",
+			program:       "var a = 1;\nprint a;\n\na = 2; print a;",
+			expectedLines: []evaluator.LineCount{{Line: 1, Count: 1}, {Line: 2, Count: 1}, {Line: 4, Count: 1}},
+		},
+		{
+			name:    "if",
+			program: "fun sign(n) {\n  if (n < 0) {\n    return -1;\n  } else {\n    return 1;\n  }\n}\nsign(1);\nsign(2);",
+			expectedLines: []evaluator.LineCount{
+				{Line: 1, Count: 1}, {Line: 2, Count: 2}, {Line: 3, Count: 0}, {Line: 5, Count: 2}, {Line: 8, Count: 1}, {Line: 9, Count: 1},
+			},
+			expectedBranches: []evaluator.BranchCount{{Line: 2, Count: 2, Taken: [2]int{0, 2}}},
+		},
+		{
+			name:    "if without else",
+			program: "if (true) {\n  print 1;\n}\nif (false) {\n  print 2;\n}",
+			expectedLines: []evaluator.LineCount{
+				{Line: 1, Count: 1}, {Line: 2, Count: 1}, {Line: 4, Count: 1}, {Line: 5, Count: 0},
+			},
+			expectedBranches: []evaluator.BranchCount{
+				{Line: 1, Count: 1, Taken: [2]int{1, 0}}, {Line: 4, Count: 1, Taken: [2]int{0, 1}},
+			},
+		},
+		{
+			name:    "for loop",
+			program: "for (var i = 0; i < 3;\n     i = i + 1) {\n  print i;\n}",
+			expectedLines: []evaluator.LineCount{
+				{Line: 1, Count: 1}, {Line: 2, Count: 3}, {Line: 3, Count: 3},
+			},
+			expectedBranches: []evaluator.BranchCount{{Line: 1, Count: 1, Taken: [2]int{3, 1}, IsLoop: true}},
+		},
+		{
+			name:    "loop never entered",
+			program: "var i = 0;\nwhile (i > 0) {\n  i = i - 1;\n}",
+			expectedLines: []evaluator.LineCount{
+				{Line: 1, Count: 1}, {Line: 2, Count: 1}, {Line: 3, Count: 0},
+			},
+			expectedBranches: []evaluator.BranchCount{{Line: 2, Count: 1, Taken: [2]int{0, 1}, IsLoop: true}},
+		},
+		{
+			name:    "function never called",
+			program: "fun f() {\n  if (true) {\n    print 1;\n  }\n}",
+			expectedLines: []evaluator.LineCount{
+				{Line: 1, Count: 1}, {Line: 2, Count: 0}, {Line: 3, Count: 0},
+			},
+			expectedBranches: []evaluator.BranchCount{{Line: 2, Count: 0, Taken: [2]int{0, 0}}},
+		},
+		{
+			name:    "error",
+			program: "print 1;\nprint 1 + nil;\nprint 2;",
+			expectedLines: []evaluator.LineCount{
+				{Line: 1, Count: 1}, {Line: 2, Count: 1}, {Line: 3, Count: 0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, _ := lexer.Tokenize(bytes.NewBufferString(test.program))
+			statements, parserErr := parser.Parse(tokens)
+			if parserErr != nil {
+				t.Fatalf("Expected no parser error, got %v", parserErr)
+			}
+			coverage := evaluator.NewCoverage()
+			coverage.Add(statements)
+			env := evaluator.NewEnvironment()
+			env.SetCoverage(coverage)
+			for _, statement := range statements {
+				if err := statement.Execute(env, bytes.NewBuffer(nil)); err != nil {
+					break
+				}
+			}
+			if lines := coverage.Lines(); !slices.Equal(lines, test.expectedLines) {
+				t.Errorf("Expected lines %v, got %v", test.expectedLines, lines)
+			}
+			if branches := coverage.Branches(); !slices.Equal(branches, test.expectedBranches) && len(branches)+len(test.expectedBranches) > 0 {
+				t.Errorf("Expected branches %v, got %v", test.expectedBranches, branches)
+			}
+		})
+	}
+}
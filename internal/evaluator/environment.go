@@ -1,41 +1,169 @@
 package evaluator
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
+// Environment holds the variables of one scope. The global scope keeps its
+// variables by name, since globals can be declared at any point, such as from
+// the REPL. Every other scope stores its variables in the slots that the
+// resolver assigned them when the program was parsed.
 type Environment struct {
-	mem    map[string]Value
-	parent *Environment
+	mem     map[string]Value
+	slots   []Value
+	parent  *Environment
+	globals *Environment
+
+	// The global scope also keeps track of the function calls in progress
+	// and the memory allocated by a program, and profiles its calls and
+	// counts its statements.
+	depth       int
+	maxDepth    int
+	allocated   int
+	memoryLimit int
+	profile     *Profile
+	coverage    *Coverage
 }
 
+// DefaultMaxCallDepth is how deeply function calls can nest unless
+// SetMaxCallDepth says otherwise. Each call takes a few kilobytes of Go
+// stack, so this stays well clear of the limit at which Go aborts the
+// process.
+const DefaultMaxCallDepth = 10000
+
 func NewEnvironment() *Environment {
-	return &Environment{mem: make(map[string]Value)}
+	e := &Environment{mem: make(map[string]Value), maxDepth: DefaultMaxCallDepth}
+	e.globals = e
+	return e
+}
+
+// SetMaxCallDepth limits how deeply function calls can nest before the
+// program fails with a stack overflow. Tail calls do not count.
+func (e *Environment) SetMaxCallDepth(depth int) {
+	e.globals.maxDepth = depth
+}
+
+// enterCall records the start of a function call, failing if too many are in
+// progress. Each successful call must be followed by exitCall.
+func (e *Environment) enterCall() *RuntimeError {
+	if e.globals.depth >= e.globals.maxDepth {
+		return NewRuntimeError("Stack overflow.")
+	}
+	e.globals.depth++
+	return nil
 }
 
+func (e *Environment) exitCall() {
+	e.globals.depth--
+}
+
+// Get returns the value of a global variable.
 func (e *Environment) Get(name string) (Value, *RuntimeError) {
-	if val, ok := e.mem[name]; ok {
+	if val, ok := e.globals.mem[name]; ok {
 		return val, nil
 	}
-	if e.parent != nil {
-		return e.parent.Get(name)
-	}
-	return nil, NewRuntimeError(fmt.Sprintf("Undefined variable: %q", name))
+	return Nil, undefinedVariable(name)
 }
 
+// Declare binds a global variable, replacing any previous value.
 func (e *Environment) Declare(name string, val Value) {
-	e.mem[name] = val
+	e.globals.mem[name] = val
 }
 
+// Set assigns to a global variable that has already been declared.
 func (e *Environment) Set(name string, val Value) *RuntimeError {
-	if _, ok := e.mem[name]; ok {
-		e.mem[name] = val
+	if _, ok := e.globals.mem[name]; ok {
+		e.globals.mem[name] = val
 		return nil
 	}
-	if e.parent != nil {
-		return e.parent.Set(name, val)
+	return undefinedVariable(name)
+}
+
+// Names returns the names of the global variables, sorted.
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.globals.mem))
+	for name := range e.globals.mem {
+		names = append(names, name)
 	}
-	return NewRuntimeError(fmt.Sprintf("Undefined variable: %q", name))
+	sort.Strings(names)
+	return names
+}
+
+// CreateScope returns a new local scope with the given number of slots, each
+// undefined until its variable is declared.
+func (e *Environment) CreateScope(size int) *Environment {
+	slots := make([]Value, size)
+	for i := range slots {
+		slots[i] = undefined
+	}
+	return &Environment{slots: slots, parent: e, globals: e.globals}
+}
+
+// Define sets a slot of this scope when its variable is declared.
+func (e *Environment) Define(slot int, val Value) {
+	e.slots[slot] = val
+}
+
+func (e *Environment) ancestor(depth int) *Environment {
+	env := e
+	for range depth {
+		env = env.parent
+	}
+	return env
+}
+
+// GetAt returns the value of the local variable in the given slot of the
+// scope depth levels up. The name is only used to report a variable that has
+// not been declared yet.
+func (e *Environment) GetAt(depth, slot int, name string) (Value, *RuntimeError) {
+	val := e.ancestor(depth).slots[slot]
+	if val.kind == kindUndefined {
+		return Nil, undefinedVariable(name)
+	}
+	return val, nil
+}
+
+// SetAt assigns to the local variable in the given slot of the scope depth
+// levels up.
+func (e *Environment) SetAt(depth, slot int, name string, val Value) *RuntimeError {
+	env := e.ancestor(depth)
+	if env.slots[slot].kind == kindUndefined {
+		return undefinedVariable(name)
+	}
+	env.slots[slot] = val
+	return nil
+}
+
+// declared reports whether the local variable at binding has been declared.
+func (e *Environment) declared(binding Binding) bool {
+	return e.ancestor(binding.Depth).slots[binding.Slot].kind != kindUndefined
 }
 
-func (e *Environment) CreateScope() *Environment {
-	return &Environment{mem: make(map[string]Value), parent: e}
+// lookup returns the value of the variable at binding, going to its fallback
+// if it is a local that has not been declared yet.
+func (e *Environment) lookup(binding Binding, name string) (Value, *RuntimeError) {
+	for binding.Local && binding.Fallback != nil && !e.declared(binding) {
+		binding = *binding.Fallback
+	}
+	if binding.Local {
+		return e.GetAt(binding.Depth, binding.Slot, name)
+	}
+	return e.Get(name)
+}
+
+// assign sets the variable at binding, going to its fallback in the same way
+// as lookup.
+func (e *Environment) assign(binding Binding, name string, val Value) *RuntimeError {
+	for binding.Local && binding.Fallback != nil && !e.declared(binding) {
+		binding = *binding.Fallback
+	}
+	if binding.Local {
+		return e.SetAt(binding.Depth, binding.Slot, name, val)
+	}
+	return e.Set(name, val)
+}
+
+func undefinedVariable(name string) *RuntimeError {
+	return NewRuntimeError(fmt.Sprintf("Undefined variable: %q", name))
 }
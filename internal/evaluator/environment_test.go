@@ -2,8 +2,8 @@ package evaluator
 
 import "testing"
 
-func value(v any) Value {
-	return &ValueLiteral{Literal: v}
+func value(n float64) Value {
+	return Number(n)
 }
 
 func TestEnvironment(t *testing.T) {
@@ -31,38 +31,76 @@ func TestEnvironment(t *testing.T) {
 	env.Declare("b", value(3))
 	assertEnv(t, env, "b", 3)
 
-	innerEnv := env.CreateScope()
+	innerEnv := env.CreateScope(1)
 
 	assertEnv(t, innerEnv, "b", 3)
 
-	innerEnv.Declare("c", value(4))
-	assertEnv(t, innerEnv, "c", 4)
-
-	innerEnv.Declare("b", value(5))
-	assertEnv(t, innerEnv, "b", 5)
-
 	if innerEnv.Set("a", value(5)) != nil {
 		t.Errorf("Expected no error for assigning to a")
 	}
 
-	_, err = env.Get("c")
-	if err == nil {
-		t.Errorf("Expected error, got nil")
+	assertEnv(t, env, "a", 5)
+}
+
+func TestEnvironmentSlots(t *testing.T) {
+	env := NewEnvironment()
+	outer := env.CreateScope(2)
+	inner := outer.CreateScope(1)
+
+	if _, err := inner.GetAt(1, 0, "a"); err == nil {
+		t.Errorf("Expected error for a slot that is not defined, got nil")
+	}
+	if inner.SetAt(1, 0, "a", value(1)) == nil {
+		t.Errorf("Expected error for assigning to a slot that is not defined, got nil")
 	}
 
-	assertEnv(t, env, "b", 3)
+	outer.Define(0, value(1))
+	outer.Define(1, value(2))
+	inner.Define(0, value(3))
+	assertSlot(t, inner, 0, 0, 3)
+	assertSlot(t, inner, 1, 0, 1)
+	assertSlot(t, inner, 1, 1, 2)
 
-	assertEnv(t, env, "a", 5)
+	if err := inner.SetAt(1, 1, "b", value(4)); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	assertSlot(t, outer, 0, 1, 4)
+
+	// Scopes share the globals of the environment they were created from.
+	inner.Declare("c", value(5))
+	assertEnv(t, env, "c", 5)
 }
 
-func assertEnv(t *testing.T, env *Environment, key string, value any) {
+func assertEnv(t *testing.T, env *Environment, key string, value float64) {
 	t.Helper()
 	found, err := env.Get(key)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-	val := found.(*ValueLiteral)
-	if val.Literal != value {
+	if n, ok := found.AsNumber(); !ok || n != value {
 		t.Errorf("Expected %v for key %s, got %v", value, key, found)
 	}
 }
+
+func assertSlot(t *testing.T, env *Environment, depth, slot int, value float64) {
+	t.Helper()
+	found, err := env.GetAt(depth, slot, "")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+		return
+	}
+	if n, ok := found.AsNumber(); !ok || n != value {
+		t.Errorf("Expected %v at depth %d slot %d, got %v", value, depth, slot, found)
+	}
+}
+
+func TestEnvironmentNames(t *testing.T) {
+	env := NewEnvironment()
+	env.Declare("b", value(1))
+	env.Declare("a", value(2))
+	env.CreateScope(0)
+	names := env.Names()
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Expected [a b], got %v", names)
+	}
+}
@@ -14,9 +14,37 @@ func NewRuntimeError(msg string) *RuntimeError {
 }
 
 func (e *RuntimeError) Code() int {
+	var exitErr *ExitError
+	if errors.As(e.err, &exitErr) {
+		return exitErr.Status
+	}
 	return 70
 }
 
+// Error returns the message to report. A requested exit has nothing to report.
 func (e *RuntimeError) Error() string {
+	var exitErr *ExitError
+	if errors.As(e.err, &exitErr) {
+		return ""
+	}
 	return fmt.Sprintf("Runtime Error: %s", e.err.Error())
 }
+
+func (e *RuntimeError) Unwrap() error {
+	return e.err
+}
+
+// ExitError stops the program with the given exit status. It travels up
+// through statements like any other runtime error, so that the embedding
+// program decides how to exit.
+type ExitError struct {
+	Status int
+}
+
+func NewExitError(status int) *RuntimeError {
+	return &RuntimeError{err: &ExitError{Status: status}}
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Status)
+}
@@ -1,58 +1,53 @@
 package evaluator
 
 import (
-	"errors"
+	"fmt"
 	"io"
 )
 
 func (e *ExpressionLiteral) Evaluate(env *Environment, output io.Writer) (Value, *RuntimeError) {
-	return &ValueLiteral{Literal: e.Literal}, nil
+	return LiteralValue(e.Literal), nil
 }
 
 func (e *ExpressionGroup) Evaluate(env *Environment, output io.Writer) (Value, *RuntimeError) {
 	return e.Child.Evaluate(env, output)
 }
 
-func evaluateToLiteral(exp Expression, env *Environment, output io.Writer) (*ValueLiteral, *RuntimeError) {
+func evaluateToLiteral(exp Expression, env *Environment, output io.Writer) (Value, *RuntimeError) {
 	val, err := exp.Evaluate(env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
-	literal, ok := val.(*ValueLiteral)
-	if !ok {
-		return nil, NewRuntimeError("Expected literal")
+	if val.Kind() == KindObject {
+		return Nil, NewRuntimeError("Expected literal")
 	}
-	return literal, nil
+	return val, nil
 }
 
 func (e *ExpressionUnary) Evaluate(env *Environment, output io.Writer) (Value, *RuntimeError) {
 	child, err := e.Child.Evaluate(env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
 	switch e.Operator {
 	case UnaryOperatorBang:
-		return &ValueLiteral{Literal: !child.Bool()}, nil
+		return Bool(!child.Bool()), nil
 	case UnaryOperatorMinus:
-		val, ok := child.(*ValueLiteral)
-		if !ok {
-			return nil, NewRuntimeError("Expected number after '-'")
-		}
-		n, ok := val.Literal.(float64)
+		n, ok := child.AsNumber()
 		if !ok {
-			return nil, NewRuntimeError("Expected number after '-'")
+			return Nil, NewRuntimeError("Expected number after '-'")
 		}
-		return &ValueLiteral{Literal: -n}, nil
+		return Number(-n), nil
 	}
 	panic("Unknown unary operator")
 }
 
-func getNums(left, right *ValueLiteral) (float64, float64, *RuntimeError) {
-	leftNum, ok := left.Literal.(float64)
+func getNums(left, right Value) (float64, float64, *RuntimeError) {
+	leftNum, ok := left.AsNumber()
 	if !ok {
 		return 0, 0, NewRuntimeError("Expected number")
 	}
-	rightNum, ok := right.Literal.(float64)
+	rightNum, ok := right.AsNumber()
 	if !ok {
 		return 0, 0, NewRuntimeError("Expected number")
 	}
@@ -68,73 +63,76 @@ func (e *ExpressionBinary) Evaluate(env *Environment, output io.Writer) (Value,
 	}
 	left, err := evaluateToLiteral(e.Left, env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
 	right, err := evaluateToLiteral(e.Right, env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
 	switch e.Operator {
 	case BinaryOperatorMultiply:
 		leftNum, rightNum, err := getNums(left, right)
 		if err != nil {
-			return nil, err
+			return Nil, err
 		}
-		return &ValueLiteral{Literal: leftNum * rightNum}, nil
+		return Number(leftNum * rightNum), nil
 	case BinaryOperatorDivide:
 		leftNum, rightNum, err := getNums(left, right)
 		if err != nil {
-			return nil, err
+			return Nil, err
 		}
 		if rightNum == 0 {
-			return nil, NewRuntimeError("Division by zero")
+			return Nil, NewRuntimeError("Division by zero")
 		}
-		return &ValueLiteral{Literal: leftNum / rightNum}, nil
+		return Number(leftNum / rightNum), nil
 	case BinaryOperatorAdd:
 		leftNum, rightNum, err := getNums(left, right)
 		if err != nil {
-			leftStr, ok1 := left.Literal.(string)
-			rightStr, ok2 := right.Literal.(string)
+			leftStr, ok1 := left.AsString()
+			rightStr, ok2 := right.AsString()
 			if !ok1 || !ok2 {
-				return nil, NewRuntimeError("Can only add numbers or strings")
+				return Nil, NewRuntimeError("Can only add numbers or strings")
 			}
-			return &ValueLiteral{Literal: leftStr + rightStr}, nil
+			if err := env.allocate(stringSize + len(leftStr) + len(rightStr)); err != nil {
+				return Nil, err
+			}
+			return String(leftStr + rightStr), nil
 		}
-		return &ValueLiteral{Literal: leftNum + rightNum}, nil
+		return Number(leftNum + rightNum), nil
 	case BinaryOperatorSubtract:
 		leftNum, rightNum, err := getNums(left, right)
 		if err != nil {
-			return nil, err
+			return Nil, err
 		}
-		return &ValueLiteral{Literal: leftNum - rightNum}, nil
+		return Number(leftNum - rightNum), nil
 	case BinaryOperatorGreater:
 		leftNum, rightNum, err := getNums(left, right)
 		if err != nil {
-			return nil, err
+			return Nil, err
 		}
-		return &ValueLiteral{Literal: leftNum > rightNum}, nil
+		return Bool(leftNum > rightNum), nil
 	case BinaryOperatorGreaterEqual:
 		leftNum, rightNum, err := getNums(left, right)
 		if err != nil {
-			return nil, err
+			return Nil, err
 		}
-		return &ValueLiteral{Literal: leftNum >= rightNum}, nil
+		return Bool(leftNum >= rightNum), nil
 	case BinaryOperatorLess:
 		leftNum, rightNum, err := getNums(left, right)
 		if err != nil {
-			return nil, err
+			return Nil, err
 		}
-		return &ValueLiteral{Literal: leftNum < rightNum}, nil
+		return Bool(leftNum < rightNum), nil
 	case BinaryOperatorLessEqual:
 		leftNum, rightNum, err := getNums(left, right)
 		if err != nil {
-			return nil, err
+			return Nil, err
 		}
-		return &ValueLiteral{Literal: leftNum <= rightNum}, nil
+		return Bool(leftNum <= rightNum), nil
 	case BinaryOperatorEqual:
-		return &ValueLiteral{Literal: left.Literal == right.Literal}, nil
+		return Bool(left.Equal(right)), nil
 	case BinaryOperatorNotEqual:
-		return &ValueLiteral{Literal: left.Literal != right.Literal}, nil
+		return Bool(!left.Equal(right)), nil
 	}
 	panic("Unknown binary operator")
 }
@@ -142,94 +140,174 @@ func (e *ExpressionBinary) Evaluate(env *Environment, output io.Writer) (Value,
 func evalOr(left, right Expression, env *Environment, output io.Writer) (Value, *RuntimeError) {
 	leftVal, err := left.Evaluate(env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
 	if leftVal.Bool() {
 		return leftVal, nil
 	}
 	rightVal, err := right.Evaluate(env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
 	if rightVal.Bool() {
 		return rightVal, nil
 	}
-	return &ValueLiteral{Literal: false}, nil
+	return Bool(false), nil
 }
 
 func evalAnd(left, right Expression, env *Environment, output io.Writer) (Value, *RuntimeError) {
 	leftVal, err := left.Evaluate(env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
 	if !leftVal.Bool() {
-		return &ValueLiteral{Literal: false}, nil
+		return Bool(false), nil
 	}
 	rightVal, err := right.Evaluate(env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
 	if !rightVal.Bool() {
-		return &ValueLiteral{Literal: false}, nil
+		return Bool(false), nil
 	}
 	return rightVal, nil
 }
 
 func (e *ExpressionVariable) Evaluate(env *Environment, _ io.Writer) (Value, *RuntimeError) {
-	return env.Get(e.Name)
+	return env.lookup(e.Binding, e.Name)
 }
 
 func (e *ExpressionAssignment) Evaluate(env *Environment, output io.Writer) (Value, *RuntimeError) {
 	result, err := e.Expr.Evaluate(env, output)
 	if err != nil {
-		return nil, err
+		return Nil, err
 	}
-	if err := env.Set(e.Name, result); err != nil {
-		return nil, err
+	if err = env.assign(e.Binding, e.Name, result); err != nil {
+		return Nil, err
 	}
 	return result, nil
 }
 
 func (e *ExpressionCall) Evaluate(env *Environment, output io.Writer) (Value, *RuntimeError) {
+	callee, args, err := e.evaluateOperands(env, output)
+	if err != nil {
+		return Nil, err
+	}
+	return call(env, callee, args, output)
+}
+
+// evaluateOperands evaluates the callee and then the arguments of a call.
+func (e *ExpressionCall) evaluateOperands(env *Environment, output io.Writer) (Value, []Value, *RuntimeError) {
 	callee, err := e.Callee.Evaluate(env, output)
 	if err != nil {
-		return nil, err
+		return Nil, nil, err
 	}
-	function, ok := callee.(*ValueClosure)
-	if !ok {
-		return nil, NewRuntimeError("Callee must be a function.")
+	switch callee.Object().(type) {
+	case *ValueClosure, *ValueNative:
+	default:
+		return Nil, nil, NewRuntimeError("Callee must be a function.")
 	}
 
 	args := make([]Value, 0, len(e.Args))
 	for _, arg := range e.Args {
 		argVal, err := arg.Evaluate(env, output)
 		if err != nil {
-			return nil, err
+			return Nil, nil, err
 		}
 		args = append(args, argVal)
 	}
+	return callee, args, nil
+}
 
-	if len(args) > len(function.Params) {
-		return nil, NewRuntimeError("Incorrect number of arguments.")
+// call applies a function to its arguments. A function that ends by returning
+// a tail call hands that call back instead of making it, and call loops round
+// to make it in its place, so tail calls do not grow the Go stack.
+func call(env *Environment, callee Value, args []Value, output io.Writer) (Value, *RuntimeError) {
+	entered := false
+	profile := env.profiler()
+	if profile != nil {
+		defer profile.unwind(profile.depth)
 	}
+	for {
+		if native, ok := callee.Object().(*ValueNative); ok {
+			if profile != nil {
+				profile.enter(Frame{Name: native.Name})
+			}
+			return callNative(env, native, args)
+		}
+		function := callee.Object().(*ValueClosure)
 
-	functionEnv := function.Env.CreateScope()
-	for i, arg := range args {
-		functionEnv.Declare(function.Params[i], arg)
-	}
+		if len(args) > function.Arity() {
+			return Nil, NewRuntimeError("Incorrect number of arguments.")
+		}
 
-	if len(args) < len(function.Params) {
-		// partial application
-		return &ValueClosure{Env: functionEnv, Body: function.Body, Params: function.Params[len(args):]}, nil
-	}
+		if len(args) < function.Arity() {
+			// partial application
+			if err := env.allocate(closureSize + slotSize*(len(function.Bound)+len(args))); err != nil {
+				return Nil, err
+			}
+			bound := make([]Value, 0, len(function.Bound)+len(args))
+			bound = append(append(bound, function.Bound...), args...)
+			partial := *function
+			partial.Bound = bound
+			return ObjectValue(&partial), nil
+		}
 
-	if err := function.Body.Execute(functionEnv, output); err != nil {
-		var returnErr *ReturnError
-		if errors.As(err.err, &returnErr) {
-			return returnErr.val, nil
+		// Tail calls made in this loop take the place of the first call, so
+		// only that one counts towards the call depth.
+		if !entered {
+			if err := function.Env.enterCall(); err != nil {
+				return Nil, err
+			}
+			defer function.Env.exitCall()
+			entered = true
 		}
-		return nil, err
+
+		if profile != nil {
+			profile.enter(Frame{Name: function.Name, Line: function.Line})
+		}
+
+		if err := env.allocate(scopeSize + slotSize*len(function.Params)); err != nil {
+			return Nil, err
+		}
+		functionEnv := function.Env.CreateScope(len(function.Params))
+		for i, arg := range function.Bound {
+			functionEnv.Define(i, arg)
+		}
+		for i, arg := range args {
+			functionEnv.Define(len(function.Bound)+i, arg)
+		}
+
+		err := function.Body.Execute(functionEnv, output)
+		if err == nil {
+			return Nil, nil
+		}
+		switch signal := err.err.(type) {
+		case *ReturnError:
+			return signal.val, nil
+		case *TailCallError:
+			if profile != nil {
+				profile.exit()
+			}
+			callee, args = signal.callee, signal.args
+			continue
+		}
+		return Nil, err
 	}
+}
 
-	return &ValueLiteral{Literal: nil}, nil
+func (e *ExpressionGet) Evaluate(env *Environment, output io.Writer) (Value, *RuntimeError) {
+	object, err := e.Object.Evaluate(env, output)
+	if err != nil {
+		return Nil, err
+	}
+	module, ok := object.Object().(*ValueModule)
+	if !ok {
+		return Nil, NewRuntimeError("Only modules have properties.")
+	}
+	member, ok := module.Members[e.Name]
+	if !ok {
+		return Nil, NewRuntimeError(fmt.Sprintf("Undefined property %q on module %s.", e.Name, module.Name))
+	}
+	return member, nil
 }
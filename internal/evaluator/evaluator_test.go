@@ -192,8 +192,7 @@ func TestEvaluator(t *testing.T) {
 			if err != nil {
 				return
 			}
-			val := result.(*evaluator.ValueLiteral)
-			if val.Literal != test.expected {
+			if result.Literal() != test.expected {
 				t.Errorf("Expected %v, got %v", test.expected, result)
 			}
 		})
@@ -50,16 +50,42 @@ type ExpressionBinary struct {
 	Right    Expression
 }
 
+// Binding locates a local variable, as worked out by the resolver: Depth
+// counts the scopes between a use and the declaration, and Slot indexes the
+// variable within that scope. Variables that are not Local are globals, which
+// are looked up by name.
+//
+// A use inside a function may be bound to a declaration later on in an
+// enclosing block. Until that declaration has run, the use goes to Fallback
+// instead, the binding the name had where the function was declared.
+type Binding struct {
+	Local    bool
+	Depth    int
+	Slot     int
+	Fallback *Binding
+}
+
 type ExpressionVariable struct {
-	Name string
+	Name    string
+	Binding Binding
 }
 
 type ExpressionAssignment struct {
-	Name string
-	Expr Expression
+	Name    string
+	Expr    Expression
+	Binding Binding
 }
 
+// ExpressionCall calls a function. Tail is set by the resolver on a call that
+// a function returns directly, which can then be made without keeping the
+// function's own call around.
 type ExpressionCall struct {
 	Callee Expression
 	Args   []Expression
+	Tail   bool
+}
+
+type ExpressionGet struct {
+	Object Expression
+	Name   string
 }
@@ -0,0 +1,155 @@
+package evaluator
+
+import "fmt"
+
+// Approximate sizes in bytes of what programs allocate, used to enforce a
+// memory limit. They only need to be roughly in proportion to the real ones.
+const (
+	scopeSize   = 48
+	slotSize    = 16
+	closureSize = 64
+	stringSize  = 16
+	listSize    = 24
+	mapSize     = 48
+	entrySize   = 48
+)
+
+// MemoryLimitError is raised when a program allocates more than the memory
+// limit set on its environment.
+type MemoryLimitError struct {
+	Limit int
+}
+
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("Memory limit of %d bytes exceeded.", e.Limit)
+}
+
+// SetMemoryLimit caps the approximate number of bytes a program can allocate
+// for strings, closures, scopes, lists and maps over its run. The total only
+// ever grows, so the limit bounds the work a program does as well as the
+// memory it holds. A limit of 0 means no limit.
+func (e *Environment) SetMemoryLimit(bytes int) {
+	e.globals.memoryLimit = bytes
+}
+
+// Allocated returns the approximate number of bytes allocated so far.
+func (e *Environment) Allocated() int {
+	return e.globals.allocated
+}
+
+// allocate counts bytes towards the memory limit, failing once it is
+// exceeded. Constant folding evaluates expressions without an environment,
+// and nothing is counted then.
+func (e *Environment) allocate(bytes int) *RuntimeError {
+	if e == nil {
+		return nil
+	}
+	globals := e.globals
+	globals.allocated += bytes
+	if globals.memoryLimit > 0 && globals.allocated > globals.memoryLimit {
+		return &RuntimeError{err: &MemoryLimitError{Limit: globals.memoryLimit}}
+	}
+	return nil
+}
+
+// sizeOf returns the approximate size of a value, not counting the values
+// that it contains.
+func sizeOf(value Value) int {
+	if s, ok := value.AsString(); ok {
+		return stringSize + len(s)
+	}
+	switch v := value.Object().(type) {
+	case *ValueClosure:
+		return closureSize + slotSize*len(v.Bound)
+	case *ValueList:
+		return listSize + slotSize*len(v.Elements)
+	case *ValueMap:
+		return mapSize + entrySize*v.Len()
+	}
+	return 0
+}
+
+// deepSizeOf returns the approximate size of a value together with the values
+// nested in it. Each list, map or closure is counted once, however many times
+// it is reached, and not at all if it is already in seen.
+func deepSizeOf(value Value, seen map[Object]bool) int {
+	if value.Kind() != KindObject {
+		return sizeOf(value)
+	}
+	if seen[value.Object()] {
+		return 0
+	}
+	seen[value.Object()] = true
+	size := sizeOf(value)
+	switch v := value.Object().(type) {
+	case *ValueClosure:
+		for _, bound := range v.Bound {
+			size += deepSizeOf(bound, seen)
+		}
+	case *ValueList:
+		for _, element := range v.Elements {
+			size += deepSizeOf(element, seen)
+		}
+	case *ValueMap:
+		for _, key := range v.keys {
+			size += len(key) + deepSizeOf(v.values[key], seen)
+		}
+	}
+	return size
+}
+
+// collectionSize returns the number of elements in a list or map, or -1 for
+// any other value.
+func collectionSize(value Value) int {
+	switch v := value.Object().(type) {
+	case *ValueList:
+		return len(v.Elements)
+	case *ValueMap:
+		return v.Len()
+	}
+	return -1
+}
+
+// callNative calls a native function. When there is a memory limit, what the
+// native allocated is estimated from its result, including the values nested
+// in it but not the arguments themselves, and from how much the lists and maps
+// it was given grew. A native with a Cost is charged that before it runs
+// instead of being charged for its result.
+func callNative(env *Environment, native *ValueNative, args []Value) (Value, *RuntimeError) {
+	if native.Arity >= 0 && len(args) != native.Arity {
+		return Nil, NewRuntimeError(fmt.Sprintf("%s: expected %d arguments but got %d.", native.Name, native.Arity, len(args)))
+	}
+	if env.globals.memoryLimit == 0 {
+		return native.Fn(args)
+	}
+	if native.Cost != nil {
+		if err := env.allocate(native.Cost(args)); err != nil {
+			return Nil, err
+		}
+	}
+	sizes := make([]int, len(args))
+	for i, arg := range args {
+		sizes[i] = collectionSize(arg)
+	}
+	result, err := native.Fn(args)
+	if err != nil {
+		return Nil, err
+	}
+	allocated := 0
+	seen := make(map[Object]bool)
+	for i, arg := range args {
+		if arg.Kind() == KindObject {
+			seen[arg.Object()] = true
+		}
+		if size := collectionSize(arg); size > sizes[i] {
+			allocated += (size - sizes[i]) * entrySize
+		}
+	}
+	if native.Cost == nil {
+		allocated += deepSizeOf(result, seen)
+	}
+	if err := env.allocate(allocated); err != nil {
+		return Nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package evaluator_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+func TestMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		program     string
+		limit       int
+		expectError bool
+	}{
+		{
+			name:        "string doubling",
+			program:     "var s = \"ab\"; while (true) { s = s + s; }",
+			limit:       1 << 20,
+			expectError: true,
+		},
+		{
+			name:        "closures",
+			program:     "fun wrap(f) { fun g() { return f(); } return g; } fun id() { return 1; } var f = id; while (true) { f = wrap(f); }",
+			limit:       1 << 20,
+			expectError: true,
+		},
+		{
+			name:        "scopes",
+			program:     "while (true) { var a = 1; }",
+			limit:       1 << 20,
+			expectError: true,
+		},
+		{
+			name:        "partial application",
+			program:     "fun add(a, b) { return a + b; } var i = 0; while (true) { add(i); i = i + 1; }",
+			limit:       1 << 20,
+			expectError: true,
+		},
+		{
+			name:        "growing a list",
+			program:     "var xs = newList(); while (true) { push(xs, 1); }",
+			limit:       1 << 20,
+			expectError: true,
+		},
+		{
+			name:        "nested lists",
+			program:     "var xs = nested(100000);",
+			limit:       1 << 20,
+			expectError: true,
+		},
+		{
+			name:    "list that contains itself",
+			program: "var xs = cycle();",
+			limit:   1 << 20,
+		},
+		{
+			name:        "cost checked before the call",
+			program:     "expensive();",
+			limit:       1 << 20,
+			expectError: true,
+		},
+		{
+			name:    "within the limit",
+			program: "var s = \"\"; for (var i = 0; i < 100; i = i + 1) { s = s + \"x\"; }",
+			limit:   1 << 20,
+		},
+		{
+			name:    "no limit",
+			program: "var s = \"ab\"; for (var i = 0; i < 20; i = i + 1) { s = s + s; }",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, _ := lexer.Tokenize(bytes.NewBufferString(test.program))
+			statements, parserErr := parser.Parse(tokens)
+			if parserErr != nil {
+				t.Fatalf("Expected no parser error, got %v", parserErr)
+			}
+			env := evaluator.NewEnvironment()
+			env.SetMemoryLimit(test.limit)
+			env.Declare("newList", evaluator.ObjectValue(&evaluator.ValueNative{Name: "newList", Arity: 0, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+				return evaluator.ObjectValue(&evaluator.ValueList{}), nil
+			}}))
+			env.Declare("push", evaluator.ObjectValue(&evaluator.ValueNative{Name: "push", Arity: 2, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+				list := args[0].Object().(*evaluator.ValueList)
+				list.Elements = append(list.Elements, args[1])
+				return args[0], nil
+			}}))
+			// nested returns a list of one element, which holds n lists.
+			env.Declare("nested", evaluator.ObjectValue(&evaluator.ValueNative{Name: "nested", Arity: 1, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+				n, _ := args[0].AsNumber()
+				inner := &evaluator.ValueList{}
+				for range int(n) {
+					inner.Elements = append(inner.Elements, evaluator.ObjectValue(&evaluator.ValueList{}))
+				}
+				return evaluator.ObjectValue(&evaluator.ValueList{Elements: []evaluator.Value{evaluator.ObjectValue(inner)}}), nil
+			}}))
+			env.Declare("cycle", evaluator.ObjectValue(&evaluator.ValueNative{Name: "cycle", Arity: 0, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+				list := &evaluator.ValueList{}
+				list.Elements = append(list.Elements, evaluator.ObjectValue(list))
+				return evaluator.ObjectValue(list), nil
+			}}))
+			env.Declare("expensive", evaluator.ObjectValue(&evaluator.ValueNative{Name: "expensive", Arity: 0, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+				t.Errorf("Expected expensive not to be called")
+				return evaluator.Nil, nil
+			}, Cost: func(args []evaluator.Value) int {
+				return 1 << 30
+			}}))
+			var err *evaluator.RuntimeError
+			for _, statement := range statements {
+				if err = statement.Execute(env, bytes.NewBuffer(nil)); err != nil {
+					break
+				}
+			}
+			if !test.expectError {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				if env.Allocated() == 0 {
+					t.Errorf("Expected allocations to be counted")
+				}
+				return
+			}
+			var limitErr *evaluator.MemoryLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Expected memory limit error, got %v", err)
+			}
+			if err.Error() != "Runtime Error: Memory limit of 1048576 bytes exceeded." {
+				t.Errorf("Expected memory limit message, got %q", err.Error())
+			}
+		})
+	}
+}
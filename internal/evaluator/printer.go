@@ -24,45 +24,52 @@ func (e *ExpressionGroup) String() string {
 	return fmt.Sprintf("(group %s)", e.Child.String())
 }
 
-func (e *ExpressionUnary) String() string {
-	switch e.Operator {
+func (o UnaryOperator) String() string {
+	switch o {
 	case UnaryOperatorMinus:
-		return fmt.Sprintf("(- %s)", e.Child.String())
+		return "-"
 	case UnaryOperatorBang:
-		return fmt.Sprintf("(! %s)", e.Child.String())
+		return "!"
 	}
 	panic("Unknown unary operator")
 }
 
-func (e *ExpressionBinary) String() string {
-	var op string
-	switch e.Operator {
+func (e *ExpressionUnary) String() string {
+	return fmt.Sprintf("(%s %s)", e.Operator.String(), e.Child.String())
+}
+
+func (o BinaryOperator) String() string {
+	switch o {
 	case BinaryOperatorMultiply:
-		op = "*"
+		return "*"
 	case BinaryOperatorDivide:
-		op = "/"
+		return "/"
 	case BinaryOperatorAdd:
-		op = "+"
+		return "+"
 	case BinaryOperatorSubtract:
-		op = "-"
+		return "-"
 	case BinaryOperatorGreater:
-		op = ">"
+		return ">"
 	case BinaryOperatorGreaterEqual:
-		op = ">="
+		return ">="
 	case BinaryOperatorLess:
-		op = "<"
+		return "<"
 	case BinaryOperatorLessEqual:
-		op = "<="
+		return "<="
 	case BinaryOperatorEqual:
-		op = "=="
+		return "=="
 	case BinaryOperatorNotEqual:
-		op = "!="
+		return "!="
 	case BinaryOperatorAnd:
-		op = "and"
+		return "and"
 	case BinaryOperatorOr:
-		op = "or"
+		return "or"
 	}
-	return fmt.Sprintf("(%s %s %s)", op, e.Left.String(), e.Right.String())
+	panic("Unknown binary operator")
+}
+
+func (e *ExpressionBinary) String() string {
+	return fmt.Sprintf("(%s %s %s)", e.Operator.String(), e.Left.String(), e.Right.String())
 }
 
 func (e *ExpressionVariable) String() string {
@@ -84,3 +91,7 @@ func (e *ExpressionCall) String() string {
 	}
 	return fmt.Sprintf("%s(%s)", callee, strings.Join(args, ", "))
 }
+
+func (e *ExpressionGet) String() string {
+	return fmt.Sprintf("%s.%s", e.Object.String(), e.Name)
+}
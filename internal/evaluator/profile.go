@@ -0,0 +1,168 @@
+package evaluator
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Frame identifies a function in a profile by its name and the line it was
+// declared on. Natives have no line.
+type Frame struct {
+	Name string
+	Line int
+}
+
+// ScriptFrame is the frame at the bottom of every stack in a profile, which
+// stands for the top level of the program. Its name is in parentheses rather
+// than angle brackets, which pprof would strip.
+var ScriptFrame = Frame{Name: "(script)"}
+
+// Profile counts the calls made to each function and the time spent in it,
+// split by the stack of calls that led there. Time is wall-clock time, so it
+// includes time spent sleeping or waiting for input. A call to a function
+// that is already on the stack is counted along with that earlier call, so
+// that recursion does not make the stacks any deeper.
+type Profile struct {
+	now   func() time.Time
+	start time.Time
+	last  time.Time
+	root  *profileNode
+	// stack holds the node of each call in progress, innermost last.
+	stack []*profileNode
+	depth int
+}
+
+type profileNode struct {
+	frame    Frame
+	parent   *profileNode
+	children map[Frame]*profileNode
+	calls    int
+	time     time.Duration
+}
+
+// Sample is the total for one stack of calls. Stack lists the frames from
+// the innermost call out to ScriptFrame. Time does not include the time
+// spent in calls made further down the stack.
+type Sample struct {
+	Stack []Frame
+	Calls int
+	Time  time.Duration
+}
+
+// NewProfile starts a profile that tells the time with now.
+func NewProfile(now func() time.Time) *Profile {
+	root := &profileNode{frame: ScriptFrame, calls: 1}
+	start := now()
+	return &Profile{now: now, start: start, last: start, root: root, stack: []*profileNode{root}}
+}
+
+// SetProfile records the calls the program makes in p. A nil profile turns
+// profiling off.
+func (e *Environment) SetProfile(p *Profile) {
+	e.globals.profile = p
+}
+
+// profiler returns the profile calls are recorded in, or nil if there is none.
+// Constant folding evaluates expressions without an environment, and nothing
+// is recorded then.
+func (e *Environment) profiler() *Profile {
+	if e == nil {
+		return nil
+	}
+	return e.globals.profile
+}
+
+// Start returns the time the profile was started.
+func (p *Profile) Start() time.Time {
+	return p.start
+}
+
+// Duration returns the time from the start of the profile to the last call
+// it recorded.
+func (p *Profile) Duration() time.Duration {
+	return p.last.Sub(p.start)
+}
+
+// current returns the node of the innermost call in progress.
+func (p *Profile) current() *profileNode {
+	return p.stack[len(p.stack)-1]
+}
+
+// advance charges the time since the last event to the current frame.
+func (p *Profile) advance() {
+	now := p.now()
+	p.current().time += now.Sub(p.last)
+	p.last = now
+}
+
+// enter records a call to frame from the current frame. If frame is already
+// on the stack, the call goes to the node of that earlier call. The nodes
+// along a path each have a different frame, so the search is short.
+func (p *Profile) enter(frame Frame) {
+	p.advance()
+	current := p.current()
+	node := current
+	for node != nil && node.frame != frame {
+		node = node.parent
+	}
+	if node == nil {
+		var ok bool
+		node, ok = current.children[frame]
+		if !ok {
+			node = &profileNode{frame: frame, parent: current}
+			if current.children == nil {
+				current.children = make(map[Frame]*profileNode)
+			}
+			current.children[frame] = node
+		}
+	}
+	node.calls++
+	p.stack = append(p.stack, node)
+	p.depth++
+}
+
+// exit records the return from the current frame.
+func (p *Profile) exit() {
+	p.advance()
+	p.stack[len(p.stack)-1] = nil
+	p.stack = p.stack[:len(p.stack)-1]
+	p.depth--
+}
+
+// unwind returns from frames until only depth of them are left, as when a
+// call ends with an error.
+func (p *Profile) unwind(depth int) {
+	for p.depth > depth {
+		p.exit()
+	}
+}
+
+// Samples returns the totals for every stack of calls that was made, callers
+// before the functions they called.
+func (p *Profile) Samples() []Sample {
+	p.advance()
+	samples := []Sample{}
+	// path holds the frames from ScriptFrame down to the node being visited.
+	var path []Frame
+	var visit func(node *profileNode)
+	visit = func(node *profileNode) {
+		path = append(path, node.frame)
+		stack := slices.Clone(path)
+		slices.Reverse(stack)
+		samples = append(samples, Sample{Stack: stack, Calls: node.calls, Time: node.time})
+		children := make([]*profileNode, 0, len(node.children))
+		for _, child := range node.children {
+			children = append(children, child)
+		}
+		slices.SortFunc(children, func(a, b *profileNode) int {
+			return cmp.Or(cmp.Compare(a.frame.Name, b.frame.Name), cmp.Compare(a.frame.Line, b.frame.Line))
+		})
+		for _, child := range children {
+			visit(child)
+		}
+		path = path[:len(path)-1]
+	}
+	visit(p.root)
+	return samples
+}
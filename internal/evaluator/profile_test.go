@@ -0,0 +1,134 @@
+package evaluator_test
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+func TestProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  string
+		expected []string
+	}{
+		{
+			name:    "nested calls",
+			program: "fun inner() { work(5); }\nfun outer() {\n  work(1); inner(); inner();\n}\nouter();\nwork(2);",
+			expected: []string{
+				"(script) 1 0s",
+				"(script);outer:2 1 0s",
+				"(script);outer:2;inner:1 2 0s",
+				"(script);outer:2;inner:1;work 2 10ms",
+				"(script);outer:2;work 1 1ms",
+				"(script);work 1 2ms",
+			},
+		},
+		{
+			name:    "time between calls",
+			program: "fun f() { var i = work(0); work(3); }\nf(); f();",
+			expected: []string{
+				"(script) 1 0s",
+				"(script);f:1 2 0s",
+				"(script);f:1;work 4 6ms",
+			},
+		},
+		{
+			name:    "tail calls",
+			program: "fun loop(n) {\n  if (n == 0) { return work(3); }\n  return loop(n - 1);\n}\nloop(3);",
+			expected: []string{
+				"(script) 1 0s",
+				"(script);loop:1 4 0s",
+				"(script);work 1 3ms",
+			},
+		},
+		{
+			name:    "deep recursion",
+			program: "fun f(n) {\n  if (n > 0) { f(n - 1); } else { work(1); }\n}\nf(9000);",
+			expected: []string{
+				"(script) 1 0s",
+				"(script);f:1 9001 0s",
+				"(script);f:1;work 1 1ms",
+			},
+		},
+		{
+			name:    "mutual recursion",
+			program: "fun even(n) { if (n == 0) { work(1); return 0; } return odd(n - 1) + 0; }\nfun odd(n) { work(1); return even(n - 1) + 0; }\neven(4);",
+			expected: []string{
+				"(script) 1 0s",
+				"(script);even:1 3 0s",
+				"(script);even:1;odd:2 2 0s",
+				"(script);even:1;odd:2;work 2 2ms",
+				"(script);even:1;work 1 1ms",
+			},
+		},
+		{
+			name:    "partial application",
+			program: "fun add(a, b) { work(1); return a + b; }\nvar inc = add(1);\ninc(2); inc(3);",
+			expected: []string{
+				"(script) 1 0s",
+				"(script);add:1 2 0s",
+				"(script);add:1;work 2 2ms",
+			},
+		},
+		{
+			name:    "errors",
+			program: "fun fail() { work(1); return 1 + nil; }\nfun ok() { work(1); }\nfail();\nok();",
+			expected: []string{
+				"(script) 1 0s",
+				"(script);fail:1 1 0s",
+				"(script);fail:1;work 1 1ms",
+				"(script);ok:2 1 0s",
+				"(script);ok:2;work 1 1ms",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, _ := lexer.Tokenize(bytes.NewBufferString(test.program))
+			statements, parserErr := parser.Parse(tokens)
+			if parserErr != nil {
+				t.Fatalf("Expected no parser error, got %v", parserErr)
+			}
+			now := time.Unix(0, 0)
+			profile := evaluator.NewProfile(func() time.Time { return now })
+			env := evaluator.NewEnvironment()
+			env.SetProfile(profile)
+			env.Declare("work", evaluator.ObjectValue(&evaluator.ValueNative{Name: "work", Arity: 1, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+				ms, _ := args[0].AsNumber()
+				now = now.Add(time.Duration(ms) * time.Millisecond)
+				return evaluator.Nil, nil
+			}}))
+			// Errors are ignored, so that the calls made after one show that
+			// the profile has returned to the top level.
+			for _, statement := range statements {
+				statement.Execute(env, bytes.NewBuffer(nil))
+			}
+
+			actual := []string{}
+			for _, sample := range profile.Samples() {
+				frames := []string{}
+				for i := len(sample.Stack) - 1; i >= 0; i-- {
+					frame := sample.Stack[i]
+					if frame.Line > 0 {
+						frames = append(frames, fmt.Sprintf("%s:%d", frame.Name, frame.Line))
+					} else {
+						frames = append(frames, frame.Name)
+					}
+				}
+				actual = append(actual, fmt.Sprintf("%s %d %s", strings.Join(frames, ";"), sample.Calls, sample.Time))
+			}
+			if !slices.Equal(actual, test.expected) {
+				t.Errorf("Expected samples\n%s\ngot\n%s", strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 )
 
+// Statement is a statement of a program. Each kind of statement records the
+// Line it starts on in the source, or 0 if that is not known.
 type Statement interface {
 	String() string
 	Execute(env *Environment, output io.Writer) *RuntimeError
@@ -13,6 +15,7 @@ type Statement interface {
 
 type ExpressionStatement struct {
 	Expression Expression
+	Line       int
 }
 
 func (e *ExpressionStatement) String() string {
@@ -20,12 +23,14 @@ func (e *ExpressionStatement) String() string {
 }
 
 func (e *ExpressionStatement) Execute(env *Environment, output io.Writer) *RuntimeError {
+	env.cover(e)
 	_, err := e.Expression.Evaluate(env, output)
 	return err
 }
 
 type PrintStatement struct {
 	Expression Expression
+	Line       int
 }
 
 func (e *PrintStatement) String() string {
@@ -33,6 +38,7 @@ func (e *PrintStatement) String() string {
 }
 
 func (e *PrintStatement) Execute(env *Environment, output io.Writer) *RuntimeError {
+	env.cover(e)
 	result, err := e.Expression.Evaluate(env, output)
 	if err != nil {
 		return err
@@ -42,8 +48,10 @@ func (e *PrintStatement) Execute(env *Environment, output io.Writer) *RuntimeErr
 }
 
 type VarStatement struct {
-	Name string
-	Expr Expression
+	Name    string
+	Expr    Expression
+	Binding Binding
+	Line    int
 }
 
 func (e *VarStatement) String() string {
@@ -54,7 +62,8 @@ func (e *VarStatement) String() string {
 }
 
 func (e *VarStatement) Execute(env *Environment, output io.Writer) *RuntimeError {
-	var value Value = &ValueLiteral{Literal: nil}
+	env.cover(e)
+	value := Nil
 	if e.Expr != nil {
 		result, err := e.Expr.Evaluate(env, output)
 		if err != nil {
@@ -62,12 +71,19 @@ func (e *VarStatement) Execute(env *Environment, output io.Writer) *RuntimeError
 		}
 		value = result
 	}
-	env.Declare(e.Name, value)
+	if e.Binding.Local {
+		env.Define(e.Binding.Slot, value)
+	} else {
+		env.Declare(e.Name, value)
+	}
 	return nil
 }
 
+// BlockStatement runs its statements in a new scope with Size slots, one for
+// each variable or function declared directly in the block.
 type BlockStatement struct {
 	Statements []Statement
+	Size       int
 }
 
 func (e *BlockStatement) String() string {
@@ -79,7 +95,10 @@ func (e *BlockStatement) String() string {
 }
 
 func (e *BlockStatement) Execute(env *Environment, output io.Writer) *RuntimeError {
-	innerEnv := env.CreateScope()
+	if err := env.allocate(scopeSize + slotSize*e.Size); err != nil {
+		return err
+	}
+	innerEnv := env.CreateScope(e.Size)
 	for _, stmt := range e.Statements {
 		err := stmt.Execute(innerEnv, output)
 		if err != nil {
@@ -93,6 +112,7 @@ type IfStatement struct {
 	Condition Expression
 	Then      *BlockStatement
 	Else      *BlockStatement
+	Line      int
 }
 
 func (e *IfStatement) String() string {
@@ -103,13 +123,17 @@ func (e *IfStatement) String() string {
 }
 
 func (e *IfStatement) Execute(env *Environment, output io.Writer) *RuntimeError {
+	env.cover(e)
 	condition, err := e.Condition.Evaluate(env, output)
 	if err != nil {
 		return err
 	}
 	if condition.Bool() {
+		env.branch(e, 0)
 		return e.Then.Execute(env, output)
-	} else if e.Else != nil {
+	}
+	env.branch(e, 1)
+	if e.Else != nil {
 		return e.Else.Execute(env, output)
 	}
 	return nil
@@ -118,6 +142,7 @@ func (e *IfStatement) Execute(env *Environment, output io.Writer) *RuntimeError
 type WhileStatement struct {
 	Condition Expression
 	Body      *BlockStatement
+	Line      int
 }
 
 func (e *WhileStatement) String() string {
@@ -125,14 +150,17 @@ func (e *WhileStatement) String() string {
 }
 
 func (e *WhileStatement) Execute(env *Environment, output io.Writer) *RuntimeError {
+	env.cover(e)
 	for {
 		condition, err := e.Condition.Evaluate(env, output)
 		if err != nil {
 			return err
 		}
 		if !condition.Bool() {
+			env.branch(e, 1)
 			break
 		}
+		env.branch(e, 0)
 		if err := e.Body.Execute(env, output); err != nil {
 			return err
 		}
@@ -141,9 +169,11 @@ func (e *WhileStatement) Execute(env *Environment, output io.Writer) *RuntimeErr
 }
 
 type FunStatement struct {
-	Name   string
-	Body   *BlockStatement
-	Params []string
+	Name    string
+	Body    *BlockStatement
+	Params  []string
+	Binding Binding
+	Line    int
 }
 
 func (e *FunStatement) String() string {
@@ -151,13 +181,22 @@ func (e *FunStatement) String() string {
 }
 
 func (e *FunStatement) Execute(env *Environment, output io.Writer) *RuntimeError {
-	closure := &ValueClosure{Env: env, Body: e.Body, Params: e.Params}
-	env.Declare(e.Name, closure)
+	env.cover(e)
+	if err := env.allocate(closureSize); err != nil {
+		return err
+	}
+	closure := ObjectValue(&ValueClosure{Name: e.Name, Line: e.Line, Env: env, Body: e.Body, Params: e.Params})
+	if e.Binding.Local {
+		env.Define(e.Binding.Slot, closure)
+	} else {
+		env.Declare(e.Name, closure)
+	}
 	return nil
 }
 
 type ReturnStatement struct {
 	Expr Expression
+	Line int
 }
 
 func (e *ReturnStatement) String() string {
@@ -175,7 +214,26 @@ func (e *ReturnError) Error() string {
 	return e.val.String()
 }
 
+// TailCallError unwinds a function that returns the result of a call, so
+// that the caller can make the call in its place.
+type TailCallError struct {
+	callee Value
+	args   []Value
+}
+
+func (e *TailCallError) Error() string {
+	return "tail call"
+}
+
 func (e *ReturnStatement) Execute(env *Environment, output io.Writer) *RuntimeError {
+	env.cover(e)
+	if call, ok := e.Expr.(*ExpressionCall); ok && call.Tail {
+		callee, args, err := call.evaluateOperands(env, output)
+		if err != nil {
+			return err
+		}
+		return &RuntimeError{err: &TailCallError{callee: callee, args: args}}
+	}
 	value, err := e.Expr.Evaluate(env, output)
 	if err != nil {
 		return err
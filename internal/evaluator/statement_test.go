@@ -2,6 +2,7 @@ package evaluator_test
 
 import (
 	"bytes"
+	"runtime/debug"
 	"testing"
 
 	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
@@ -151,6 +152,11 @@ func TestExecuteStatements(t *testing.T) {
 			program:     "fun add(a, b) {print a + b;} add(1, 2, 3);",
 			expectError: true,
 		},
+		{
+			name:        "property on non-module",
+			program:     "var a = 1; print a.b;",
+			expectError: true,
+		},
 		{
 			name:        "incorrect callee type",
 			program:     "var add = 1; add();",
@@ -213,3 +219,106 @@ func TestExecuteStatements(t *testing.T) {
 		})
 	}
 }
+
+func TestTailCalls(t *testing.T) {
+	// Without tail calls, a million nested Lox calls would need far more
+	// than this much Go stack, and exceeding it aborts the test binary.
+	defer debug.SetMaxStack(debug.SetMaxStack(16 << 20))
+
+	tests := []struct {
+		name     string
+		program  string
+		expected string
+	}{
+		{
+			name:     "self recursion",
+			program:  "fun loop(n, acc) { if (n == 0) { return acc; } return loop(n - 1, acc + 2); } print loop(1000000, 0);",
+			expected: "2e+06\n",
+		},
+		{
+			name:     "mutual recursion",
+			program:  "fun isEven(n) { if (n == 0) { return true; } return isOdd(n - 1); } fun isOdd(n) { if (n == 0) { return false; } return isEven(n - 1); } print isEven(1000001);",
+			expected: "false\n",
+		},
+		{
+			name:     "tail call through a local closure",
+			program:  "fun outer(n) { fun step(i) { if (i == n) { return i; } return step(i + 1); } return step(0); } print outer(1000000);",
+			expected: "1e+06\n",
+		},
+		{
+			name:     "tail call returning a partial application",
+			program:  "fun add(a, b) { return a + b; } fun adder(a) { return add(a); } print adder(1)(2);",
+			expected: "3\n",
+		},
+		{
+			name:     "tail call to a native",
+			program:  "fun answer() { return native(); } print answer();",
+			expected: "42\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, _ := lexer.Tokenize(bytes.NewBufferString(test.program))
+			statements, parserErr := parser.Parse(tokens)
+			if parserErr != nil {
+				t.Fatalf("Expected no parser error, got %v", parserErr)
+			}
+			env := evaluator.NewEnvironment()
+			env.Declare("native", evaluator.ObjectValue(&evaluator.ValueNative{Name: "native", Arity: 0, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+				return evaluator.Number(42), nil
+			}}))
+			output := bytes.NewBuffer(nil)
+			for _, statement := range statements {
+				if err := statement.Execute(env, output); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+			}
+			if output.String() != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestCallDepth(t *testing.T) {
+	program := "fun down(n) { if (n == 0) { return 0; } return 1 + down(n - 1); } fun loop(n) { if (n == 0) { return 0; } return loop(n - 1); }"
+	tokens, _ := lexer.Tokenize(bytes.NewBufferString(program))
+	statements, _ := parser.Parse(tokens)
+	env := evaluator.NewEnvironment()
+	env.SetMaxCallDepth(100)
+	for _, statement := range statements {
+		if err := statement.Execute(env, bytes.NewBuffer(nil)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	run := func(source string) *evaluator.RuntimeError {
+		tokens, _ := lexer.Tokenize(bytes.NewBufferString(source))
+		statements, _ := parser.Parse(tokens)
+		for _, statement := range statements {
+			if err := statement.Execute(env, bytes.NewBuffer(nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := run("down(99);"); err != nil {
+		t.Errorf("Expected no error within the limit, got %v", err)
+	}
+	err := run("down(100);")
+	if err == nil {
+		t.Fatalf("Expected stack overflow, got nil")
+	}
+	if err.Error() != "Runtime Error: Stack overflow." || err.Code() != 70 {
+		t.Errorf("Expected stack overflow with code 70, got %q with code %d", err.Error(), err.Code())
+	}
+	// The calls that failed no longer count once the error has unwound them.
+	if err := run("down(99);"); err != nil {
+		t.Errorf("Expected no error after a stack overflow, got %v", err)
+	}
+	if err := run("loop(100000);"); err != nil {
+		t.Errorf("Expected tail calls not to count towards the limit, got %v", err)
+	}
+}
@@ -2,35 +2,345 @@ package evaluator
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
-type Value interface {
+// Kind says which sort of data a Value holds.
+type Kind uint8
+
+const (
+	KindNil Kind = iota
+	KindBool
+	KindNumber
+	KindString
+	KindObject
+	// kindUndefined marks a local variable that has not been declared yet.
+	// Programs never see it.
+	kindUndefined
+)
+
+// Value is a Lox value. Nil, booleans and numbers are held in the struct
+// itself, so making them does not allocate. Strings, functions, modules and
+// collections are held by reference. The zero Value is nil.
+type Value struct {
+	kind Kind
+	num  float64
+	obj  Object
+}
+
+// Object is a value that lives on the heap and is shared by reference.
+type Object interface {
 	String() string
-	Bool() bool
+	Type() string
+}
+
+// Nil is the nil value.
+var Nil = Value{}
+
+var undefined = Value{kind: kindUndefined}
+
+func Bool(b bool) Value {
+	if b {
+		return Value{kind: KindBool, num: 1}
+	}
+	return Value{kind: KindBool}
+}
+
+func Number(n float64) Value {
+	return Value{kind: KindNumber, num: n}
+}
+
+func String(s string) Value {
+	return Value{kind: KindString, obj: stringObject(s)}
+}
+
+func ObjectValue(obj Object) Value {
+	return Value{kind: KindObject, obj: obj}
+}
+
+// LiteralValue converts a number, string, bool or nil, as held by an
+// ExpressionLiteral, to a Value.
+func LiteralValue(literal any) Value {
+	switch l := literal.(type) {
+	case float64:
+		return Number(l)
+	case string:
+		return String(l)
+	case bool:
+		return Bool(l)
+	}
+	return Nil
+}
+
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+func (v Value) IsNil() bool {
+	return v.kind == KindNil
 }
 
-type ValueLiteral struct {
-	Literal any // number, string, bool, nil
+// AsBool returns the value of a boolean, and whether it is one. Use Bool for
+// a value's truthiness.
+func (v Value) AsBool() (bool, bool) {
+	return v.num != 0, v.kind == KindBool
 }
 
-func (v *ValueLiteral) String() string {
-	return fmt.Sprintf("%v", v.Literal)
+func (v Value) AsNumber() (float64, bool) {
+	return v.num, v.kind == KindNumber
 }
 
-func (v *ValueLiteral) Bool() bool {
-	return v.Literal != nil && v.Literal != false
+func (v Value) AsString() (string, bool) {
+	if v.kind != KindString {
+		return "", false
+	}
+	return string(v.obj.(stringObject)), true
 }
 
+// Object returns the object a value refers to, or nil if it is not an
+// object.
+func (v Value) Object() Object {
+	if v.kind != KindObject {
+		return nil
+	}
+	return v.obj
+}
+
+// Literal returns the number, string, bool or nil that a value holds, or nil
+// for an object.
+func (v Value) Literal() any {
+	switch v.kind {
+	case KindBool:
+		return v.num != 0
+	case KindNumber:
+		return v.num
+	case KindString:
+		return string(v.obj.(stringObject))
+	}
+	return nil
+}
+
+// Equal reports whether two values that are not objects are equal.
+func (v Value) Equal(other Value) bool {
+	return v.kind == other.kind && v.num == other.num && v.obj == other.obj
+}
+
+func (v Value) String() string {
+	switch v.kind {
+	case KindBool:
+		return strconv.FormatBool(v.num != 0)
+	case KindNumber:
+		return strconv.FormatFloat(v.num, 'g', -1, 64)
+	case KindString, KindObject:
+		return v.obj.String()
+	}
+	return "<nil>"
+}
+
+func (v Value) Bool() bool {
+	switch v.kind {
+	case KindNil:
+		return false
+	case KindBool:
+		return v.num != 0
+	}
+	return true
+}
+
+func (v Value) Type() string {
+	switch v.kind {
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString, KindObject:
+		return v.obj.Type()
+	}
+	return "nil"
+}
+
+// stringObject holds the text of a string value.
+type stringObject string
+
+func (s stringObject) String() string {
+	return string(s)
+}
+
+func (s stringObject) Type() string {
+	return "string"
+}
+
+// ValueClosure is a function declared in Lox. Name and Line come from its
+// declaration. Bound holds the arguments already given to a partially
+// applied function, which fill the first of its Params.
 type ValueClosure struct {
+	Name   string
+	Line   int
 	Env    *Environment
 	Body   *BlockStatement
 	Params []string
+	Bound  []Value
+}
+
+// Arity returns the number of arguments the function still expects.
+func (v *ValueClosure) Arity() int {
+	return len(v.Params) - len(v.Bound)
 }
 
 func (v *ValueClosure) String() string {
 	return "<function>"
 }
 
-func (v *ValueClosure) Bool() bool {
+func (v *ValueClosure) Type() string {
+	return "function"
+}
+
+// ValueNative is a function implemented in Go. An Arity of -1 accepts any
+// number of arguments.
+type ValueNative struct {
+	Name  string
+	Arity int
+	Fn    func(args []Value) (Value, *RuntimeError)
+	// Cost, if set, estimates the bytes a call with the given arguments will
+	// allocate or write out. It is checked against the memory limit before
+	// the call, so that a native building a large result fails before it
+	// builds it.
+	Cost func(args []Value) int
+}
+
+func (v *ValueNative) String() string {
+	return "<native function>"
+}
+
+func (v *ValueNative) Type() string {
+	return "function"
+}
+
+// ValueModule is a namespace of values, such as the natives of the standard
+// library, whose members are read with the "." operator.
+type ValueModule struct {
+	Name    string
+	Members map[string]Value
+}
+
+func (v *ValueModule) String() string {
+	return fmt.Sprintf("<module %s>", v.Name)
+}
+
+func (v *ValueModule) Type() string {
+	return "module"
+}
+
+// ValueList is a mutable, ordered collection of values. It is shared by
+// reference, so changes made through one variable are seen through others.
+type ValueList struct {
+	Elements []Value
+}
+
+// inspect formats a value nested in a collection, quoting strings so that
+// they can be told apart from other values. Printing holds the collections
+// being formatted further out, so that a collection that contains itself is
+// shown as [...] or {...} where it comes round again, rather than recursing
+// forever.
+func inspect(v Value, printing map[Object]bool) string {
+	if s, ok := v.AsString(); ok {
+		return strconv.Quote(s)
+	}
+	switch collection := v.Object().(type) {
+	case *ValueList:
+		return collection.format(printing)
+	case *ValueMap:
+		return collection.format(printing)
+	}
+	return v.String()
+}
+
+func (v *ValueList) String() string {
+	return v.format(make(map[Object]bool))
+}
+
+func (v *ValueList) format(printing map[Object]bool) string {
+	if printing[v] {
+		return "[...]"
+	}
+	printing[v] = true
+	defer delete(printing, v)
+	elements := make([]string, 0, len(v.Elements))
+	for _, element := range v.Elements {
+		elements = append(elements, inspect(element, printing))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
+
+func (v *ValueList) Type() string {
+	return "list"
+}
+
+// ValueMap is a mutable mapping from strings to values that remembers the
+// order in which its keys were added. Like lists, maps are shared by
+// reference.
+type ValueMap struct {
+	keys   []string
+	values map[string]Value
+}
+
+func NewValueMap() *ValueMap {
+	return &ValueMap{values: make(map[string]Value)}
+}
+
+func (v *ValueMap) Get(key string) (Value, bool) {
+	value, ok := v.values[key]
+	return value, ok
+}
+
+func (v *ValueMap) Set(key string, value Value) {
+	if _, ok := v.values[key]; !ok {
+		v.keys = append(v.keys, key)
+	}
+	v.values[key] = value
+}
+
+func (v *ValueMap) Delete(key string) bool {
+	if _, ok := v.values[key]; !ok {
+		return false
+	}
+	delete(v.values, key)
+	for i, k := range v.keys {
+		if k == key {
+			v.keys = append(v.keys[:i], v.keys[i+1:]...)
+			break
+		}
+	}
 	return true
 }
+
+// Keys returns the keys in the order they were added.
+func (v *ValueMap) Keys() []string {
+	return append([]string{}, v.keys...)
+}
+
+func (v *ValueMap) Len() int {
+	return len(v.keys)
+}
+
+func (v *ValueMap) String() string {
+	return v.format(make(map[Object]bool))
+}
+
+func (v *ValueMap) format(printing map[Object]bool) string {
+	if printing[v] {
+		return "{...}"
+	}
+	printing[v] = true
+	defer delete(printing, v)
+	entries := make([]string, 0, len(v.keys))
+	for _, key := range v.keys {
+		entries = append(entries, fmt.Sprintf("%s: %s", strconv.Quote(key), inspect(v.values[key], printing)))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(entries, ", "))
+}
+
+func (v *ValueMap) Type() string {
+	return "map"
+}
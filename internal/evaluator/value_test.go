@@ -0,0 +1,70 @@
+package evaluator_test
+
+import (
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    evaluator.Value
+		str      string
+		typ      string
+		truthy   bool
+		expected any
+	}{
+		{"nil", evaluator.Nil, "<nil>", "nil", false, nil},
+		{"zero value", evaluator.Value{}, "<nil>", "nil", false, nil},
+		{"true", evaluator.Bool(true), "true", "bool", true, true},
+		{"false", evaluator.Bool(false), "false", "bool", false, false},
+		{"number", evaluator.Number(1.5), "1.5", "number", true, 1.5},
+		{"zero", evaluator.Number(0), "0", "number", true, 0.0},
+		{"string", evaluator.String("hi"), "hi", "string", true, "hi"},
+		{"empty string", evaluator.String(""), "", "string", true, ""},
+		{"list", evaluator.ObjectValue(&evaluator.ValueList{}), "[]", "list", true, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.value.String() != test.str {
+				t.Errorf("Expected string %q, got %q", test.str, test.value.String())
+			}
+			if test.value.Type() != test.typ {
+				t.Errorf("Expected type %s, got %s", test.typ, test.value.Type())
+			}
+			if test.value.Bool() != test.truthy {
+				t.Errorf("Expected truthiness %v, got %v", test.truthy, test.value.Bool())
+			}
+			if test.value.Literal() != test.expected {
+				t.Errorf("Expected literal %v, got %v", test.expected, test.value.Literal())
+			}
+		})
+	}
+}
+
+func TestValueEqual(t *testing.T) {
+	if !evaluator.String("a").Equal(evaluator.String("a")) {
+		t.Errorf("Expected equal strings to be equal")
+	}
+	if evaluator.Number(1).Equal(evaluator.Bool(true)) {
+		t.Errorf("Expected 1 and true to differ")
+	}
+	if evaluator.Number(0).Equal(evaluator.Nil) {
+		t.Errorf("Expected 0 and nil to differ")
+	}
+	if !evaluator.LiteralValue(nil).Equal(evaluator.Nil) {
+		t.Errorf("Expected nil literal to equal nil")
+	}
+}
+
+func TestValueAllocations(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		n, _ := evaluator.Number(2).AsNumber()
+		b := evaluator.Bool(n > 1)
+		_ = evaluator.Number(n*3).Equal(evaluator.Nil) || b.Bool()
+	})
+	if allocs != 0 {
+		t.Errorf("Expected numbers, booleans and nil not to allocate, got %v allocations", allocs)
+	}
+}
@@ -1,11 +1,20 @@
 package interpreter
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"io"
+	"math/rand/v2"
 
+	"github.com/thebenkogan/lox-interpreter/internal/astjson"
+	"github.com/thebenkogan/lox-interpreter/internal/coverage"
 	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
 	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/optimizer"
 	"github.com/thebenkogan/lox-interpreter/internal/parser"
+	"github.com/thebenkogan/lox-interpreter/internal/pprof"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
 )
 
 type InterpreterError interface {
@@ -14,31 +23,312 @@ type InterpreterError interface {
 }
 
 type Interpreter struct {
-	env    *evaluator.Environment
-	output io.Writer
+	env        *evaluator.Environment
+	input      *bufio.Reader
+	output     io.Writer
+	readRoots  []string
+	writeRoots []string
+	fs         evaluator.Value
+	args       []string
+	clock      stdlib.Clock
+	seed       uint64
+	seeded     bool
+	optimize   bool
+	maxDepth   int
+	maxMemory  int
+	profiling  bool
+	profile    *evaluator.Profile
+	covering   bool
+	coverage   *evaluator.Coverage
+	source     []byte
 }
 
-func NewInterpreter(output io.Writer) *Interpreter {
-	return &Interpreter{env: evaluator.NewEnvironment(), output: output}
+// Option configures optional behavior of an Interpreter.
+type Option func(*Interpreter)
+
+// WithReadRoots allows the fs natives to read files below the given
+// directories. Without it, programs cannot read any files.
+func WithReadRoots(roots ...string) Option {
+	return func(i *Interpreter) {
+		i.readRoots = append(i.readRoots, roots...)
+	}
+}
+
+// WithWriteRoots allows the fs natives to create, change and remove files
+// below the given directories. Without it, programs cannot write any files.
+func WithWriteRoots(roots ...string) Option {
+	return func(i *Interpreter) {
+		i.writeRoots = append(i.writeRoots, roots...)
+	}
+}
+
+// WithArgs sets the command-line arguments that programs see in the args
+// global.
+func WithArgs(args ...string) Option {
+	return func(i *Interpreter) {
+		i.args = append(i.args, args...)
+	}
+}
+
+// WithClock sets the clock that programs use to tell the time and sleep, in
+// place of the system clock.
+func WithClock(clock stdlib.Clock) Option {
+	return func(i *Interpreter) {
+		i.clock = clock
+	}
+}
+
+// WithSeed seeds the generator behind the random natives, so that programs
+// see the same numbers on every run. Without it, the seed is random.
+func WithSeed(seed uint64) Option {
+	return func(i *Interpreter) {
+		i.seed = seed
+		i.seeded = true
+	}
+}
+
+// WithOptimizer runs programs through the optimizer before executing them.
+func WithOptimizer() Option {
+	return func(i *Interpreter) {
+		i.optimize = true
+	}
+}
+
+// WithMaxCallDepth limits how deeply function calls can nest before the
+// program fails with a stack overflow, in place of
+// evaluator.DefaultMaxCallDepth.
+func WithMaxCallDepth(depth int) Option {
+	return func(i *Interpreter) {
+		i.maxDepth = depth
+	}
+}
+
+// WithMemoryLimit fails programs with an evaluator.MemoryLimitError once they
+// have allocated about this many bytes. See Environment.SetMemoryLimit.
+func WithMemoryLimit(bytes int) Option {
+	return func(i *Interpreter) {
+		i.maxMemory = bytes
+	}
+}
+
+// WithProfiling records the calls that programs make and the time spent in
+// each function, to be saved with WriteProfile.
+func WithProfiling() Option {
+	return func(i *Interpreter) {
+		i.profiling = true
+	}
+}
+
+// WithCoverage counts the statements that programs run and the branches
+// they take, to be saved with WriteLCOV and WriteCoverageHTML. It cannot be
+// combined with WithOptimizer, which removes statements that the report
+// should show as never run.
+func WithCoverage() Option {
+	return func(i *Interpreter) {
+		i.covering = true
+	}
+}
+
+// NewInterpreter returns an interpreter whose programs read from input and
+// print to output. An input that is already a *bufio.Reader is read from
+// directly, so that it can be shared with other readers of the same input.
+// It fails if a directory given to WithReadRoots or WithWriteRoots does not
+// exist, or if given both WithCoverage and WithOptimizer.
+func NewInterpreter(input io.Reader, output io.Writer, options ...Option) (*Interpreter, error) {
+	i := &Interpreter{input: bufio.NewReader(input), output: output, clock: stdlib.SystemClock()}
+	for _, option := range options {
+		option(i)
+	}
+	if i.covering && i.optimize {
+		return nil, errors.New("coverage cannot be combined with the optimizer")
+	}
+	fs, err := stdlib.FileSystem(i.readRoots, i.writeRoots)
+	if err != nil {
+		return nil, err
+	}
+	i.fs = fs
+	i.Reset()
+	return i, nil
+}
+
+// Reset discards every binding, starting over with a fresh global scope.
+func (i *Interpreter) Reset() {
+	i.env = evaluator.NewEnvironment()
+	if i.maxDepth > 0 {
+		i.env.SetMaxCallDepth(i.maxDepth)
+	}
+	i.env.SetMemoryLimit(i.maxMemory)
+	if i.profiling {
+		i.profile = evaluator.NewProfile(i.clock.Now)
+		i.env.SetProfile(i.profile)
+	}
+	if i.covering {
+		i.coverage = evaluator.NewCoverage()
+		i.env.SetCoverage(i.coverage)
+		i.source = nil
+	}
+	i.env.Declare("math", stdlib.Math())
+	i.env.Declare("string", stdlib.Strings())
+	i.env.Declare("list", stdlib.Lists())
+	i.env.Declare("map", stdlib.Maps())
+	i.env.Declare("json", stdlib.JSON())
+	i.env.Declare("fs", i.fs)
+	for name, native := range stdlib.Conversions() {
+		i.env.Declare(name, native)
+	}
+	for name, native := range stdlib.Input(i.input) {
+		i.env.Declare(name, native)
+	}
+	for name, value := range stdlib.Process(i.args) {
+		i.env.Declare(name, value)
+	}
+	for name, value := range stdlib.Output(i.output) {
+		i.env.Declare(name, value)
+	}
+	for name, value := range stdlib.Time(i.clock) {
+		i.env.Declare(name, value)
+	}
+	seed := i.seed
+	if !i.seeded {
+		seed = rand.Uint64()
+	}
+	for name, value := range stdlib.Random(rand.New(rand.NewPCG(seed, seed))) {
+		i.env.Declare(name, value)
+	}
+}
+
+// WriteProfile writes the profile of everything run since the last Reset to w
+// in pprof format. Functions are attributed to the source file filename.
+func (i *Interpreter) WriteProfile(w io.Writer, filename string) error {
+	if i.profile == nil {
+		return errors.New("profiling is not enabled")
+	}
+	return pprof.Write(w, i.profile, filename)
+}
+
+// WriteLCOV writes the coverage of everything run since the last Reset to w
+// as an LCOV report. Statements are attributed to the source file filename.
+func (i *Interpreter) WriteLCOV(w io.Writer, filename string) error {
+	if i.coverage == nil {
+		return errors.New("coverage is not enabled")
+	}
+	return coverage.WriteLCOV(w, i.coverage, filename)
+}
+
+// WriteCoverageHTML writes the coverage of everything run since the last Reset
+// to w as an HTML page showing the source of the last program passed to
+// Interpret, which is titled filename.
+func (i *Interpreter) WriteCoverageHTML(w io.Writer, filename string) error {
+	if i.coverage == nil {
+		return errors.New("coverage is not enabled")
+	}
+	return coverage.WriteHTML(w, i.coverage, filename, i.source)
+}
+
+// Globals returns the names bound in the global scope, in sorted order, along
+// with their values.
+func (i *Interpreter) Globals() ([]string, []evaluator.Value) {
+	names := i.env.Names()
+	values := make([]evaluator.Value, 0, len(names))
+	for _, name := range names {
+		value, _ := i.env.Get(name)
+		values = append(values, value)
+	}
+	return names, values
 }
 
 func (i *Interpreter) Interpret(f io.Reader) InterpreterError {
+	// The source is kept for the coverage report.
+	source := bytes.Buffer{}
+	if i.covering {
+		f = io.TeeReader(f, &source)
+	}
 	tokens, lexerErr := lexer.Tokenize(f)
+	if i.covering {
+		i.source = source.Bytes()
+	}
 	if lexerErr != nil {
 		return lexerErr
 	}
-
 	statements, parserErr := parser.Parse(tokens)
 	if parserErr != nil {
 		return parserErr
 	}
+	return i.execute(i.prepare(statements))
+}
 
+// InterpretAST executes a program that was already parsed and serialized with
+// astjson.Marshal, skipping the lexer and parser.
+func (i *Interpreter) InterpretAST(f io.Reader) InterpreterError {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return astjson.NewDecodeError(err.Error())
+	}
+	statements, decodeErr := astjson.Unmarshal(data)
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return i.execute(i.prepare(statements))
+}
+
+// Evaluate interprets a single REPL entry. If the entry is a bare expression,
+// with or without its trailing semicolon, its value is returned so that the
+// caller can echo it. Otherwise the returned value is nil.
+func (i *Interpreter) Evaluate(f io.Reader) (*evaluator.Value, InterpreterError) {
+	statements, err := i.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 1 {
+		if stmt, ok := statements[0].(*evaluator.ExpressionStatement); ok {
+			value, err := stmt.Expression.Evaluate(i.env, i.output)
+			if err != nil {
+				return nil, err
+			}
+			return &value, nil
+		}
+	}
+	if err := i.execute(statements); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Parse parses a REPL entry without running it. A bare expression missing its
+// trailing semicolon is accepted as an expression statement.
+func (i *Interpreter) Parse(f io.Reader) ([]evaluator.Statement, InterpreterError) {
+	tokens, lexerErr := lexer.Tokenize(f)
+	if lexerErr != nil {
+		return nil, lexerErr
+	}
+	statements, parserErr := parser.Parse(tokens)
+	if parserErr != nil {
+		expr, exprErr := parser.ParseExpression(tokens)
+		if exprErr != nil {
+			return nil, parserErr
+		}
+		statements = []evaluator.Statement{&evaluator.ExpressionStatement{Expression: expr}}
+	}
+	return i.prepare(statements), nil
+}
+
+// prepare applies the configured passes to a parsed program before it runs.
+func (i *Interpreter) prepare(statements []evaluator.Statement) []evaluator.Statement {
+	if i.optimize {
+		statements = optimizer.Optimize(statements)
+	}
+	if i.coverage != nil {
+		i.coverage.Add(statements)
+	}
+	return statements
+}
+
+func (i *Interpreter) execute(statements []evaluator.Statement) InterpreterError {
 	for _, statement := range statements {
 		err := statement.Execute(i.env, i.output)
 		if err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
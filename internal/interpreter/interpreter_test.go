@@ -0,0 +1,506 @@
+package interpreter_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/interpreter"
+)
+
+func newInterpreter(t *testing.T, input io.Reader, output io.Writer, options ...interpreter.Option) *interpreter.Interpreter {
+	t.Helper()
+	i, err := interpreter.NewInterpreter(input, output, options...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return i
+}
+
+// fakeClock is a clock that only moves when the program sleeps.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		program     string
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "now and clock",
+			program:  "print time.format(now()); print clock() * 1000 == now();",
+			expected: "2024-01-02T03:04:05.000Z\ntrue\n",
+		},
+		{
+			name:     "sleep advances the clock",
+			program:  "var start = now(); sleep(1500); print now() - start; print time.format(now(), \"15:04:05.000\");",
+			expected: "1500\n03:04:06.500\n",
+		},
+		{
+			name:     "format layouts",
+			program:  "print time.format(0, \"2006-01-02 15:04\"); print time.format(86400000, time.iso);",
+			expected: "1970-01-01 00:00\n1970-01-02T00:00:00.000Z\n",
+		},
+		{
+			name:     "format far from 1970",
+			program:  "print time.format(32503680000000); print time.format(-10000000000000); print time.format(8640000000000000, \"2006\");",
+			expected: "3000-01-01T00:00:00.000Z\n1653-02-10T06:13:20.000Z\n275760\n",
+		},
+		{
+			name:        "format out of range",
+			program:     "time.format(10000000000000000);",
+			expectError: "Runtime Error: time.format: argument 1 must be a time within 8.64e15 milliseconds of 1970, got number.",
+		},
+		{
+			name:     "parse far from 1970",
+			program:  "print time.parse(\"3000-01-01T00:00:00.5Z\") == 32503680000500; print time.parse(\"1600-01-01T00:00:00Z\");",
+			expected: "true\n-1.1676096e+13\n",
+		},
+		{
+			name:     "parse",
+			program:  "print time.parse(\"1970-01-01T00:00:01.5Z\"); print time.parse(\"02/01/1970\", \"02/01/2006\"); print time.parse(time.format(now())) == now();",
+			expected: "1500\n8.64e+07\ntrue\n",
+		},
+		{
+			name:        "parse failure",
+			program:     "time.parse(\"yesterday\");",
+			expectError: "Runtime Error: time.parse: cannot parse \"yesterday\" with layout \"2006-01-02T15:04:05Z07:00\".",
+		},
+		{
+			name:     "durations",
+			program:  "print time.duration(\"1m30s\"); print time.formatDuration(5400000);",
+			expected: "90000\n1h30m0s\n",
+		},
+		{
+			name:        "invalid duration",
+			program:     "time.duration(\"soon\");",
+			expectError: "Runtime Error: time.duration: invalid duration \"soon\".",
+		},
+		{
+			name:        "negative sleep",
+			program:     "sleep(-1);",
+			expectError: "Runtime Error: sleep: argument 1 must be a non-negative number, got number.",
+		},
+		{
+			name:        "sleep for NaN",
+			program:     "sleep(math.sqrt(-1));",
+			expectError: "Runtime Error: sleep: argument 1 must be a duration within 9.2e12 milliseconds either way, got number.",
+		},
+		{
+			name:     "longest durations",
+			program:  "print time.formatDuration(-9000000000000);",
+			expected: "-2500000h0m0s\n",
+		},
+		{
+			name:        "duration too long",
+			program:     "time.formatDuration(10000000000000);",
+			expectError: "Runtime Error: time.formatDuration: argument 1 must be a duration within 9.2e12 milliseconds either way, got number.",
+		},
+		{
+			name:        "infinite duration",
+			program:     "time.formatDuration(-math.exp(1000));",
+			expectError: "Runtime Error: time.formatDuration: argument 1 must be a duration within 9.2e12 milliseconds either way, got number.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+			output := bytes.NewBuffer(nil)
+			i := newInterpreter(t, strings.NewReader(""), output, interpreter.WithClock(clock))
+			err := i.Interpret(strings.NewReader(test.program))
+			if test.expectError != "" {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				} else if err.Error() != test.expectError {
+					t.Errorf("Expected error %q, got %q", test.expectError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if output.String() != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestSeed(t *testing.T) {
+	program := "print random(); print randomInt(1, 1000); print shuffle(list.of(1, 2, 3, 4, 5));"
+	run := func(options ...interpreter.Option) string {
+		output := bytes.NewBuffer(nil)
+		i := newInterpreter(t, strings.NewReader(""), output, options...)
+		if err := i.Interpret(strings.NewReader(program)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return output.String()
+	}
+	first := run(interpreter.WithSeed(42))
+	if second := run(interpreter.WithSeed(42)); first != second {
+		t.Errorf("Expected the same output for the same seed, got %q and %q", first, second)
+	}
+	if other := run(interpreter.WithSeed(43)); first == other {
+		t.Errorf("Expected different output for different seeds, got %q", first)
+	}
+}
+
+func TestFormattedOutput(t *testing.T) {
+	output := bytes.NewBuffer(nil)
+	i := newInterpreter(t, strings.NewReader(""), output)
+	program := "write(\"a\"); write(nil); print \"b\"; printf(\"%s=%05.1f;\", \"x\", 2.25); print \"\";"
+	if err := i.Interpret(strings.NewReader(program)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := "anilb\nx=002.2;\n"; output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+}
+
+func TestSharedInput(t *testing.T) {
+	input := bufio.NewReader(strings.NewReader("first\nsecond\n"))
+	output := bytes.NewBuffer(nil)
+	i := newInterpreter(t, input, output)
+	if err := i.Interpret(strings.NewReader("print readLine();")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if output.String() != "first\n" {
+		t.Errorf("Expected %q, got %q", "first\n", output.String())
+	}
+	// The interpreter reads through the given reader, leaving the rest of the
+	// input in it.
+	if line, _ := input.ReadString('\n'); line != "second\n" {
+		t.Errorf("Expected the next line to be left for other readers, got %q", line)
+	}
+}
+
+func TestReadRoots(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	_, err := interpreter.NewInterpreter(strings.NewReader(""), bytes.NewBuffer(nil), interpreter.WithReadRoots(missing))
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("%q", missing)) {
+		t.Errorf("Expected an error naming the missing root, got %v", err)
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	program := "fun down(n) { if (n == 0) { return 0; } return 1 + down(n - 1); } print down(20);"
+	i := newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil), interpreter.WithMaxCallDepth(10))
+	err := i.Interpret(strings.NewReader(program))
+	if err == nil {
+		t.Fatalf("Expected stack overflow, got nil")
+	}
+	if err.Error() != "Runtime Error: Stack overflow." || err.Code() != 70 {
+		t.Errorf("Expected stack overflow with code 70, got %q with code %d", err.Error(), err.Code())
+	}
+
+	// Unbounded recursion must fail cleanly with the default limit too.
+	i = newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil))
+	if err := i.Interpret(strings.NewReader("fun forever(n) { return 1 + forever(n); } forever(0);")); err == nil || err.Error() != "Runtime Error: Stack overflow." {
+		t.Errorf("Expected stack overflow, got %v", err)
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	program := "var xs = list.of(); while (true) { list.push(xs, \"item\"); }"
+	i := newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil), interpreter.WithMemoryLimit(100000))
+	err := i.Interpret(strings.NewReader(program))
+	if err == nil {
+		t.Fatalf("Expected memory limit error, got nil")
+	}
+	if err.Error() != "Runtime Error: Memory limit of 100000 bytes exceeded." || err.Code() != 70 {
+		t.Errorf("Expected memory limit error with code 70, got %q with code %d", err.Error(), err.Code())
+	}
+
+	// Natives are charged for everything nested in their results and for
+	// what they print, and those that build large strings are stopped before
+	// they do.
+	for _, program := range []string{
+		"var s = string.repeat(\"[[[[[[1]]]]]],\", 1000); var xs = json.parse(\"[\" + s + \"[]]\");",
+		"var s = string.repeat(\"x\", 200000000);",
+		"var s = string.replace(string.repeat(\"x\", 1000), \"x\", string.repeat(\"y\", 1000));",
+		"var s = format(\"%900000s\", \"\");",
+		"printf(\"%90000s\", \"\"); printf(\"%90000s\", \"\");",
+		"var s = string.repeat(\"x\", 60000); write(s);",
+	} {
+		i := newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil), interpreter.WithMemoryLimit(100000))
+		if err := i.Interpret(strings.NewReader(program)); err == nil || err.Error() != "Runtime Error: Memory limit of 100000 bytes exceeded." {
+			t.Errorf("Expected memory limit error for %s, got %v", program, err)
+		}
+	}
+
+	// The limit applies again after a reset.
+	i.Reset()
+	if err := i.Interpret(strings.NewReader("var s = \"ab\"; while (true) { s = s + s; }")); err == nil || err.Error() != "Runtime Error: Memory limit of 100000 bytes exceeded." {
+		t.Errorf("Expected memory limit error after reset, got %v", err)
+	}
+}
+
+func TestProfiling(t *testing.T) {
+	i := newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil))
+	if err := i.WriteProfile(bytes.NewBuffer(nil), "main.lox"); err == nil {
+		t.Errorf("Expected an error writing a profile without profiling, got nil")
+	}
+
+	clock := &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	i = newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil), interpreter.WithProfiling(), interpreter.WithClock(clock))
+	if err := i.Interpret(strings.NewReader("fun nap() { sleep(50); }\nnap();")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	profile := readProfile(t, i)
+	for _, name := range []string{"nap", "sleep", "main.lox"} {
+		if !strings.Contains(profile, name) {
+			t.Errorf("Expected %q in the profile", name)
+		}
+	}
+
+	// A reset starts a fresh profile.
+	i.Reset()
+	if err := i.Interpret(strings.NewReader("fun rest() {}\nrest();")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	profile = readProfile(t, i)
+	if strings.Contains(profile, "nap") || !strings.Contains(profile, "rest") {
+		t.Errorf("Expected only the calls since the reset in the profile")
+	}
+}
+
+// readProfile writes the interpreter's profile and returns it uncompressed.
+func readProfile(t *testing.T, i *interpreter.Interpreter) string {
+	t.Helper()
+	out := bytes.Buffer{}
+	if err := i.WriteProfile(&out, "main.lox"); err != nil {
+		t.Fatalf("Expected no error writing the profile, got %v", err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("Expected a gzip-compressed profile, got %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Expected a gzip-compressed profile, got %v", err)
+	}
+	return string(data)
+}
+
+func TestCoverage(t *testing.T) {
+	i := newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil))
+	if err := i.WriteLCOV(bytes.NewBuffer(nil), "main.lox"); err == nil {
+		t.Errorf("Expected an error writing coverage without coverage enabled, got nil")
+	}
+
+	if _, err := interpreter.NewInterpreter(strings.NewReader(""), bytes.NewBuffer(nil), interpreter.WithCoverage(), interpreter.WithOptimizer()); err == nil {
+		t.Errorf("Expected an error combining coverage with the optimizer, got nil")
+	}
+
+	i = newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil), interpreter.WithCoverage())
+	program := "var a = 1;\nif (a > 1) {\n  print a;\n}\nif (false) {\n  print a;\n}\n"
+	if err := i.Interpret(strings.NewReader(program)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lcov := bytes.Buffer{}
+	if err := i.WriteLCOV(&lcov, "main.lox"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "TN:\nSF:main.lox\nBRDA:2,0,0,0\nBRDA:2,0,1,1\nBRDA:5,0,0,0\nBRDA:5,0,1,1\nBRF:4\nBRH:2\nDA:1,1\nDA:2,1\nDA:3,0\nDA:5,1\nDA:6,0\nLF:5\nLH:3\nend_of_record\n"
+	if lcov.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, lcov.String())
+	}
+	html := bytes.Buffer{}
+	if err := i.WriteCoverageHTML(&html, "main.lox"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(html.String(), `<td class="code">if (false) {</td>`) {
+		t.Errorf("Expected the source in the page, got\n%s", html.String())
+	}
+
+	// A reset starts counting again.
+	i.Reset()
+	lcov.Reset()
+	if err := i.WriteLCOV(&lcov, "main.lox"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(lcov.String(), "DA:") {
+		t.Errorf("Expected no lines after a reset, got\n%s", lcov.String())
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     []string
+		expected    string
+		output      string
+		expectError string
+	}{
+		{
+			name:     "bare expression",
+			entries:  []string{"1 + 2"},
+			expected: "3",
+		},
+		{
+			name:     "bare expression with semicolon",
+			entries:  []string{"\"a\" + \"b\";"},
+			expected: "ab",
+		},
+		{
+			name:     "statement has no value",
+			entries:  []string{"print 1;"},
+			expected: "<none>",
+			output:   "1\n",
+		},
+		{
+			name:     "several statements have no value",
+			entries:  []string{"1; 2;"},
+			expected: "<none>",
+		},
+		{
+			name:     "multi-line entry",
+			entries:  []string{"fun add(a, b) {\n  return a + b;\n}\n", "add(2, 3)"},
+			expected: "5",
+		},
+		{
+			name:     "state carries over between entries",
+			entries:  []string{"var a = 1;", "a = a + 1;", "a"},
+			expected: "2",
+		},
+		{
+			name:        "parser error",
+			entries:     []string{"1 +"},
+			expectError: "Parser Error: Expected expression.",
+		},
+		{
+			name:        "runtime error",
+			entries:     []string{"-\"a\""},
+			expectError: "Runtime Error: Expected number after '-'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := bytes.NewBuffer(nil)
+			i := newInterpreter(t, strings.NewReader(""), output)
+			var value *evaluator.Value
+			var err interpreter.InterpreterError
+			for _, entry := range test.entries {
+				value, err = i.Evaluate(strings.NewReader(entry))
+				if err != nil {
+					break
+				}
+			}
+			if test.expectError != "" {
+				if err == nil {
+					t.Fatalf("Expected error %q, got nil", test.expectError)
+				}
+				if err.Error() != test.expectError {
+					t.Errorf("Expected error %q, got %q", test.expectError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			actual := "<none>"
+			if value != nil {
+				actual = value.String()
+			}
+			if actual != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, actual)
+			}
+			if output.String() != test.output {
+				t.Errorf("Expected output %q, got %q", test.output, output.String())
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       string
+		expected    string
+		expectError bool
+	}{
+		{"statement", "print 1;", "print 1.0", false},
+		{"expression without semicolon", "1 + 2", "(expr (+ 1.0 2.0))", false},
+		{"incomplete statement", "print 1", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			i := newInterpreter(t, strings.NewReader(""), bytes.NewBuffer(nil))
+			statements, err := i.Parse(strings.NewReader(test.entry))
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", statements)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(statements) != 1 || statements[0].String() != test.expected {
+				t.Errorf("Expected %s, got %v", test.expected, statements)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	output := bytes.NewBuffer(nil)
+	i := newInterpreter(t, strings.NewReader(""), output)
+	if err := i.Interpret(strings.NewReader("var answer = 42; fun greet() { print \"hi\"; }")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	names, values := i.Globals()
+	if !slices.IsSorted(names) {
+		t.Errorf("Expected sorted names, got %v", names)
+	}
+	index := slices.Index(names, "answer")
+	if index < 0 || values[index].String() != "42" {
+		t.Errorf("Expected answer = 42 in the globals, got %v", names)
+	}
+	if !slices.Contains(names, "greet") || !slices.Contains(names, "math") {
+		t.Errorf("Expected greet and math in the globals, got %v", names)
+	}
+
+	// Bindings survive from one entry to the next until a reset.
+	if _, err := i.Evaluate(strings.NewReader("greet();")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	i.Reset()
+	names, _ = i.Globals()
+	if slices.Contains(names, "answer") || slices.Contains(names, "greet") {
+		t.Errorf("Expected the program's bindings to be gone after a reset, got %v", names)
+	}
+	if !slices.Contains(names, "math") || !slices.Contains(names, "str") {
+		t.Errorf("Expected the built-in globals after a reset, got %v", names)
+	}
+	if _, err := i.Evaluate(strings.NewReader("answer")); err == nil {
+		t.Errorf("Expected an undefined variable error after a reset, got nil")
+	}
+	if value, err := i.Evaluate(strings.NewReader("math.floor(2.5)")); err != nil || value.String() != "2" {
+		t.Errorf("Expected the math module to work after a reset, got %v, %v", value, err)
+	}
+	if output.String() != "hi\n" {
+		t.Errorf("Expected output %q, got %q", "hi\n", output.String())
+	}
+}
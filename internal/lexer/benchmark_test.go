@@ -0,0 +1,35 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// largeProgram builds a program of roughly the given size in bytes, with the
+// mix of identifiers, numbers, strings and comments that real scripts have,
+// plus some long identifiers and numbers.
+func largeProgram(size int) string {
+	var b strings.Builder
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "// step %d\n", i)
+		fmt.Fprintf(&b, "var value_%d = %d.%d * (counter + 1) >= limit;\n", i, i, i%7)
+		fmt.Fprintf(&b, "if (value_%d != nil and !done) { print \"line %d\"; }\n", i, i)
+		if i%100 == 0 {
+			fmt.Fprintf(&b, "var %s = %s;\n", strings.Repeat("long_name", 200), strings.Repeat("9", 2000))
+		}
+	}
+	return b.String()
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	program := largeProgram(4 << 20)
+	b.SetBytes(int64(len(program)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := Tokenize(strings.NewReader(program)); err != nil {
+			b.Fatalf("Expected no lexer error, got %v", err)
+		}
+	}
+}
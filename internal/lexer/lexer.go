@@ -1,46 +1,208 @@
 package lexer
 
 import (
-	"bufio"
+	"fmt"
 	"io"
+	"strings"
 )
 
+// Tokenize reads a whole program and splits it into tokens, ending with an
+// EOF token. Characters that cannot start a token are reported together
+// once the whole source has been scanned.
 func Tokenize(file io.Reader) ([]Token, *LexerError) {
-	f := bufio.NewReader(file)
-	tokens := make([]Token, 0)
-	errors := make([]TokenError, 0)
-	line := 1
-	for {
-		char, _, err := f.ReadRune()
-		if err == io.EOF {
-			tokens = append(tokens, Token{Type: TokenTypeEOF})
-			break
-		}
-		if isWhitespace(char) {
-			continue
-		}
-		if char == '\n' {
-			line++
-			continue
-		}
-		if char == '/' && peekNext(f) == '/' {
-			_, _ = f.ReadString('\n')
-			line++
-			continue
+	source, readErr := io.ReadAll(file)
+	s := &scanner{source: string(source), line: 1}
+	s.scan()
+	if readErr != nil {
+		s.errors = append(s.errors, TokenError{line: s.line, msg: fmt.Sprintf("Cannot read source: %s.", readErr)})
+	}
+	var err *LexerError
+	if len(s.errors) > 0 {
+		err = &LexerError{Errors: s.errors}
+	}
+	return s.tokens, err
+}
+
+// scanner walks the source a byte at a time. The current token runs from
+// start to current, so lexemes are slices of the source rather than copies.
+// Only strings can hold characters outside ASCII, and they are found by
+// searching for the closing quote.
+type scanner struct {
+	source  string
+	start   int
+	current int
+	line    int
+	tokens  []Token
+	errors  []TokenError
+}
+
+func (s *scanner) scan() {
+	// Programs average a token every few bytes, so this usually saves
+	// growing the slice over and over.
+	s.tokens = make([]Token, 0, len(s.source)/4+1)
+	s.errors = make([]TokenError, 0)
+	for s.current < len(s.source) {
+		s.start = s.current
+		s.scanToken()
+	}
+	s.tokens = append(s.tokens, Token{Type: TokenTypeEOF, Line: s.line})
+}
+
+func (s *scanner) scanToken() {
+	c := s.advance()
+	switch c {
+	case ' ', '\t':
+	case '\n':
+		s.line++
+	case '@', '#', '^', '$', '&', '%':
+		s.error(fmt.Sprintf("Unexpected character: %c", c))
+	case '(':
+		s.add(TokenTypeLeftParen)
+	case ')':
+		s.add(TokenTypeRightParen)
+	case '{':
+		s.add(TokenTypeLeftBrace)
+	case '}':
+		s.add(TokenTypeRightBrace)
+	case ',':
+		s.add(TokenTypeComma)
+	case '.':
+		s.add(TokenTypeDot)
+	case '-':
+		s.add(TokenTypeMinus)
+	case '+':
+		s.add(TokenTypePlus)
+	case ';':
+		s.add(TokenTypeSemicolon)
+	case '*':
+		s.add(TokenTypeStar)
+	case '/':
+		if s.peek() == '/' {
+			s.skipComment()
+		} else {
+			s.add(TokenTypeSlash)
 		}
-		parsed, err := readToken(char, f)
-		if err != nil {
-			errors = append(errors, TokenError{line: line, msg: err.Error()})
-			continue
+	case '=':
+		s.addEither('=', TokenTypeEqualEqual, TokenTypeEqual)
+	case '!':
+		s.addEither('=', TokenTypeBangEqual, TokenTypeBang)
+	case '<':
+		s.addEither('=', TokenTypeLessEqual, TokenTypeLess)
+	case '>':
+		s.addEither('=', TokenTypeGreaterEqual, TokenTypeGreater)
+	case '"':
+		s.scanString()
+	default:
+		if isDigit(c) {
+			s.scanNumber()
+		} else if isAlpha(c) {
+			s.scanIdentifier()
 		}
-		if parsed.Type == TokenTypeUnknown {
-			continue
+		// Anything else, such as a byte of a non-ASCII character outside
+		// a string, is skipped.
+	}
+}
+
+func (s *scanner) advance() byte {
+	c := s.source[s.current]
+	s.current++
+	return c
+}
+
+// peekAt returns the byte offset bytes after the current one, or 0 past the
+// end of the source.
+func (s *scanner) peekAt(offset int) byte {
+	if s.current+offset >= len(s.source) {
+		return 0
+	}
+	return s.source[s.current+offset]
+}
+
+func (s *scanner) peek() byte {
+	return s.peekAt(0)
+}
+
+func (s *scanner) add(tokenType TokenType) {
+	s.addLiteral(tokenType, "")
+}
+
+func (s *scanner) addLiteral(tokenType TokenType, literal string) {
+	s.tokens = append(s.tokens, Token{Type: tokenType, Lexeme: s.source[s.start:s.current], Literal: literal, Line: s.line})
+}
+
+// addEither adds the two-character token if the next byte is next, and the
+// one-character token otherwise.
+func (s *scanner) addEither(next byte, two, one TokenType) {
+	if s.peek() == next {
+		s.current++
+		s.add(two)
+		return
+	}
+	s.add(one)
+}
+
+func (s *scanner) error(msg string) {
+	s.errors = append(s.errors, TokenError{line: s.line, msg: msg})
+}
+
+// skipComment skips to the end of the line, including the newline.
+func (s *scanner) skipComment() {
+	if i := strings.IndexByte(s.source[s.current:], '\n'); i >= 0 {
+		s.current += i + 1
+	} else {
+		s.current = len(s.source)
+	}
+	s.line++
+}
+
+// scanString scans a string, which may span several lines. The token takes the
+// line it starts on.
+func (s *scanner) scanString() {
+	end := strings.IndexByte(s.source[s.current:], '"')
+	if end < 0 {
+		s.current = len(s.source)
+		s.error("Unterminated string.")
+		return
+	}
+	literal := s.source[s.current : s.current+end]
+	s.current += end + 1
+	s.addLiteral(TokenTypeString, literal)
+	s.line += strings.Count(literal, "\n")
+}
+
+func (s *scanner) scanNumber() {
+	for isDigit(s.peek()) {
+		s.current++
+	}
+	if s.peek() == '.' && isDigit(s.peekAt(1)) {
+		s.current++
+		for isDigit(s.peek()) {
+			s.current++
 		}
-		tokens = append(tokens, *parsed)
 	}
-	var err *LexerError
-	if len(errors) > 0 {
-		err = &LexerError{Errors: errors}
+	s.addLiteral(TokenTypeNumber, numberLiteral(s.source[s.start:s.current]))
+}
+
+// numberLiteral writes a number the way the tokenize command prints it, with
+// a fractional part of at least one digit.
+func numberLiteral(lexeme string) string {
+	literal := lexeme
+	if !strings.Contains(lexeme, ".") {
+		literal += ".0"
+	}
+	for strings.HasSuffix(literal, "00") {
+		literal = strings.TrimSuffix(literal, "0")
+	}
+	return literal
+}
+
+func (s *scanner) scanIdentifier() {
+	for isAlphaNumeric(s.peek()) {
+		s.current++
+	}
+	tokenType := TokenTypeIdentifier
+	if reservedType, ok := reserved[s.source[s.start:s.current]]; ok {
+		tokenType = reservedType
 	}
-	return tokens, err
+	s.add(tokenType)
 }
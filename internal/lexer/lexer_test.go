@@ -2,8 +2,10 @@ package lexer
 
 import (
 	"bytes"
+	"encoding/json"
 	"reflect"
 	"slices"
+	"strings"
 	"testing"
 )
 
@@ -183,3 +185,70 @@ func TestLexer(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenLines(t *testing.T) {
+	tokens, _ := Tokenize(bytes.NewBufferString("var a;\n\"multi\nline\" b\n// comment\nc"))
+	expected := []int{1, 1, 1, 2, 3, 5, 5}
+	lines := make([]int, 0)
+	for _, token := range tokens {
+		lines = append(lines, token.Line)
+	}
+	if !slices.Equal(lines, expected) {
+		t.Errorf("Expected lines %v, got %v", expected, lines)
+	}
+}
+
+func TestTokenJSON(t *testing.T) {
+	tests := []struct {
+		token    Token
+		expected string
+	}{
+		{
+			token:    Token{Type: TokenTypeNumber, Lexeme: "12", Literal: "12.0", Line: 3},
+			expected: `{"type":"NUMBER","lexeme":"12","literal":"12.0","line":3}`,
+		},
+		{
+			token:    Token{Type: TokenTypeIdentifier, Lexeme: "foo", Line: 1},
+			expected: `{"type":"IDENTIFIER","lexeme":"foo","literal":null,"line":1}`,
+		},
+		{
+			token:    Token{Type: TokenTypeString, Lexeme: "\"\"", Literal: "", Line: 2},
+			expected: `{"type":"STRING","lexeme":"\"\"","literal":"","line":2}`,
+		},
+	}
+	for _, test := range tests {
+		encoded, err := json.Marshal(test.token)
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if string(encoded) != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, string(encoded))
+		}
+	}
+}
+
+func TestLexemes(t *testing.T) {
+	long := strings.Repeat("name", 1000)
+	digits := strings.Repeat("9", 1000)
+	tokens, err := Tokenize(bytes.NewBufferString(long + " = " + digits + ".5 >= \"é\n\";"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []Token{
+		{Type: TokenTypeIdentifier, Lexeme: long, Line: 1},
+		{Type: TokenTypeEqual, Lexeme: "=", Line: 1},
+		{Type: TokenTypeNumber, Lexeme: digits + ".5", Literal: digits + ".5", Line: 1},
+		{Type: TokenTypeGreaterEqual, Lexeme: ">=", Line: 1},
+		{Type: TokenTypeString, Lexeme: "\"é\n\"", Literal: "é\n", Line: 1},
+		{Type: TokenTypeSemicolon, Lexeme: ";", Line: 2},
+		{Type: TokenTypeEOF, Line: 2},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, token := range tokens {
+		if token != expected[i] {
+			t.Errorf("Expected token %d to be %s on line %d, got %s on line %d", i, expected[i].String(), expected[i].Line, token.String(), token.Line)
+		}
+	}
+}
@@ -1,10 +1,9 @@
 package lexer
 
 import (
-	"bufio"
-	"errors"
+	"encoding/json"
 	"fmt"
-	"strings"
+	"sort"
 )
 
 type TokenType int
@@ -71,6 +70,16 @@ var reserved = map[string]TokenType{
 	"while":  TokenTypeWhile,
 }
 
+// Keywords returns the reserved words of the language, sorted.
+func Keywords() []string {
+	keywords := make([]string, 0, len(reserved))
+	for keyword := range reserved {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	return keywords
+}
+
 func (t TokenType) String() string {
 	switch t {
 	case TokenTypeEOF:
@@ -160,136 +169,7 @@ type Token struct {
 	Type    TokenType
 	Lexeme  string
 	Literal string
-}
-
-func peekNext(stream *bufio.Reader) rune {
-	next, _ := stream.Peek(1)
-	if len(next) > 0 {
-		return rune(next[0])
-	}
-	return rune(0)
-}
-
-func readToken(s rune, stream *bufio.Reader) (*Token, error) {
-	switch s {
-	case '@':
-		fallthrough
-	case '#':
-		fallthrough
-	case '^':
-		fallthrough
-	case '$':
-		fallthrough
-	case '&':
-		fallthrough
-	case '%':
-		return nil, fmt.Errorf("Unexpected character: %s", string(s))
-	case '(':
-		return &Token{Type: TokenTypeLeftParen, Lexeme: string(s)}, nil
-	case ')':
-		return &Token{Type: TokenTypeRightParen, Lexeme: string(s)}, nil
-	case '{':
-		return &Token{Type: TokenTypeLeftBrace, Lexeme: string(s)}, nil
-	case '}':
-		return &Token{Type: TokenTypeRightBrace, Lexeme: string(s)}, nil
-	case ',':
-		return &Token{Type: TokenTypeComma, Lexeme: string(s)}, nil
-	case '.':
-		return &Token{Type: TokenTypeDot, Lexeme: string(s)}, nil
-	case '-':
-		return &Token{Type: TokenTypeMinus, Lexeme: string(s)}, nil
-	case '+':
-		return &Token{Type: TokenTypePlus, Lexeme: string(s)}, nil
-	case ';':
-		return &Token{Type: TokenTypeSemicolon, Lexeme: string(s)}, nil
-	case '*':
-		return &Token{Type: TokenTypeStar, Lexeme: string(s)}, nil
-	case '/':
-		return &Token{Type: TokenTypeSlash, Lexeme: string(s)}, nil
-	case '=':
-		if peekNext(stream) == '=' {
-			_, _ = stream.Discard(1)
-			return &Token{Type: TokenTypeEqualEqual, Lexeme: "=="}, nil
-		}
-		return &Token{Type: TokenTypeEqual, Lexeme: string(s)}, nil
-	case '!':
-		if peekNext(stream) == '=' {
-			_, _ = stream.Discard(1)
-			return &Token{Type: TokenTypeBangEqual, Lexeme: "!="}, nil
-		}
-		return &Token{Type: TokenTypeBang, Lexeme: string(s)}, nil
-	case '<':
-		if peekNext(stream) == '=' {
-			_, _ = stream.Discard(1)
-			return &Token{Type: TokenTypeLessEqual, Lexeme: "<="}, nil
-		}
-		return &Token{Type: TokenTypeLess, Lexeme: string(s)}, nil
-	case '>':
-		if peekNext(stream) == '=' {
-			_, _ = stream.Discard(1)
-			return &Token{Type: TokenTypeGreaterEqual, Lexeme: ">="}, nil
-		}
-		return &Token{Type: TokenTypeGreater, Lexeme: string(s)}, nil
-	case '"':
-		return readString(s, stream)
-	default:
-		if isDigit(s) {
-			return readNumber(s, stream)
-		}
-		if isAlpha(s) {
-			return readIdentifierOrReserved(s, stream)
-		}
-		return &Token{Type: TokenTypeUnknown, Lexeme: string(s)}, nil
-	}
-}
-
-func readString(_ rune, stream *bufio.Reader) (*Token, error) {
-	rest, err := stream.ReadString('"')
-	if err != nil {
-		return nil, errors.New("Unterminated string.")
-	}
-	literal := strings.TrimSuffix(rest, "\"")
-	return &Token{Type: TokenTypeString, Lexeme: fmt.Sprintf("\"%s\"", literal), Literal: literal}, nil
-}
-
-func readNumber(s rune, stream *bufio.Reader) (*Token, error) {
-	number := string(s)
-	for isDigit(peekNext(stream)) {
-		number += string(peekNext(stream))
-		_, _ = stream.Discard(1)
-	}
-	if peekNext(stream) == '.' {
-		nextTwo, _ := stream.Peek(2)
-		if len(nextTwo) == 2 && isDigit(rune(nextTwo[1])) {
-			_, _ = stream.Discard(1)
-			number += "."
-			for isDigit(peekNext(stream)) {
-				number += string(peekNext(stream))
-				_, _ = stream.Discard(1)
-			}
-		}
-	}
-	literal := number
-	if !strings.Contains(number, ".") {
-		literal += ".0"
-	}
-	for strings.HasSuffix(literal, "00") {
-		literal = strings.TrimSuffix(literal, "0")
-	}
-	return &Token{Type: TokenTypeNumber, Lexeme: number, Literal: literal}, nil
-}
-
-func readIdentifierOrReserved(s rune, stream *bufio.Reader) (*Token, error) {
-	ident := string(s)
-	for isAlphaNumeric(peekNext(stream)) {
-		ident += string(peekNext(stream))
-		_, _ = stream.Discard(1)
-	}
-	tokenType := TokenTypeIdentifier
-	if reservedType, ok := reserved[ident]; ok {
-		tokenType = reservedType
-	}
-	return &Token{Type: tokenType, Lexeme: ident}, nil
+	Line    int
 }
 
 func (t *Token) String() string {
@@ -305,6 +185,21 @@ func (t *Token) String() string {
 	}
 }
 
+// MarshalJSON encodes the token with its literal value, which is null for
+// tokens other than strings and numbers. The empty string is still a literal.
+func (t Token) MarshalJSON() ([]byte, error) {
+	var literal *string
+	if t.Type == TokenTypeString || t.Type == TokenTypeNumber {
+		literal = &t.Literal
+	}
+	return json.Marshal(struct {
+		Type    string  `json:"type"`
+		Lexeme  string  `json:"lexeme"`
+		Literal *string `json:"literal"`
+		Line    int     `json:"line"`
+	}{Type: t.Type.String(), Lexeme: t.Lexeme, Literal: literal, Line: t.Line})
+}
+
 type TokenError struct {
 	line int
 	msg  string
@@ -314,18 +209,14 @@ func (te *TokenError) String() string {
 	return fmt.Sprintf("[line %d] Error: %s", te.line, te.msg)
 }
 
-func isWhitespace(r rune) bool {
-	return r == ' ' || r == '\t'
-}
-
-func isDigit(r rune) bool {
-	return r >= '0' && r <= '9'
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
 }
 
-func isAlpha(r rune) bool {
-	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
 }
 
-func isAlphaNumeric(r rune) bool {
-	return isAlpha(r) || isDigit(r)
+func isAlphaNumeric(c byte) bool {
+	return isAlpha(c) || isDigit(c)
 }
@@ -0,0 +1,452 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+const maxHistory = 1000
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyNewline   = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// Editor reads lines from a terminal with cursor movement, history and
+// completion. When its input is not a terminal it falls back to reading plain
+// lines.
+type Editor struct {
+	// Complete returns the candidates for completing the word before the
+	// cursor, or nil if there are none.
+	Complete func(word string) []string
+
+	in          *os.File
+	out         io.Writer
+	reader      *bufio.Reader
+	history     []string
+	historyFile string
+}
+
+func NewEditor(in *os.File, out io.Writer) *Editor {
+	return &Editor{in: in, out: out, reader: bufio.NewReader(in)}
+}
+
+// Reader returns the buffered reader that the editor reads its input through.
+// Anything else that reads the same input must read it from here, or it may
+// take lines meant for the editor and lose its own to the editor's buffer.
+func (e *Editor) Reader() *bufio.Reader {
+	return e.reader
+}
+
+// LoadHistory reads previously entered lines from path, which is also where
+// new lines are appended. A missing file is not an error.
+func (e *Editor) LoadHistory(path string) error {
+	e.historyFile = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+	return nil
+}
+
+func (e *Editor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if e.historyFile == "" {
+		return
+	}
+	f, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// ReadLine displays the prompt and returns the next line without its newline.
+// It returns io.EOF when the input ends or the user presses Ctrl-D on an
+// empty line.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	state, err := makeRaw(int(e.in.Fd()))
+	if err != nil {
+		fmt.Fprint(e.out, prompt)
+		line, err := e.reader.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+	defer restore(int(e.in.Fd()), state)
+	return e.edit(prompt)
+}
+
+// session is the state of a single line being edited.
+type session struct {
+	editor  *Editor
+	prompt  string
+	buf     []rune
+	cursor  int
+	history int    // index into the history being shown, or len(history)
+	draft   string // the line being edited before browsing history
+}
+
+func (e *Editor) edit(prompt string) (string, error) {
+	s := &session{editor: e, prompt: prompt, history: len(e.history)}
+	s.refresh()
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(s.buf) > 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return string(s.buf), nil
+			}
+			return "", err
+		}
+		switch r {
+		case keyEnter, keyNewline:
+			return s.submit(), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(s.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			s.deleteForward()
+		case keyBackspace, keyCtrlH:
+			s.deleteBackward()
+		case keyCtrlA:
+			s.cursor = 0
+		case keyCtrlE:
+			s.cursor = len(s.buf)
+		case keyCtrlB:
+			s.left()
+		case keyCtrlF:
+			s.right()
+		case keyCtrlK:
+			s.buf = s.buf[:s.cursor]
+		case keyCtrlU:
+			s.buf = s.buf[s.cursor:]
+			s.cursor = 0
+		case keyCtrlW:
+			s.deleteWord()
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			s.previousHistory()
+		case keyCtrlN:
+			s.nextHistory()
+		case keyTab:
+			s.complete()
+		case keyCtrlR:
+			submitted, err := s.search()
+			if err != nil {
+				return "", err
+			}
+			if submitted {
+				return s.submit(), nil
+			}
+		case keyEscape:
+			if err := s.escape(); err != nil {
+				return "", err
+			}
+		default:
+			if unicode.IsPrint(r) {
+				s.insert(r)
+			}
+		}
+		s.refresh()
+	}
+}
+
+func (s *session) submit() string {
+	s.cursor = len(s.buf)
+	s.refresh()
+	fmt.Fprint(s.editor.out, "\r\n")
+	line := string(s.buf)
+	s.editor.addHistory(line)
+	return line
+}
+
+func (s *session) refresh() {
+	fmt.Fprintf(s.editor.out, "\r%s%s\x1b[K", s.prompt, string(s.buf))
+	if back := len(s.buf) - s.cursor; back > 0 {
+		fmt.Fprintf(s.editor.out, "\x1b[%dD", back)
+	}
+}
+
+func (s *session) setLine(line string) {
+	s.buf = []rune(line)
+	s.cursor = len(s.buf)
+}
+
+func (s *session) insert(r rune) {
+	s.buf = append(s.buf, 0)
+	copy(s.buf[s.cursor+1:], s.buf[s.cursor:])
+	s.buf[s.cursor] = r
+	s.cursor++
+}
+
+func (s *session) insertString(str string) {
+	for _, r := range str {
+		s.insert(r)
+	}
+}
+
+func (s *session) left() {
+	if s.cursor > 0 {
+		s.cursor--
+	}
+}
+
+func (s *session) right() {
+	if s.cursor < len(s.buf) {
+		s.cursor++
+	}
+}
+
+func (s *session) deleteBackward() {
+	if s.cursor == 0 {
+		return
+	}
+	s.buf = append(s.buf[:s.cursor-1], s.buf[s.cursor:]...)
+	s.cursor--
+}
+
+func (s *session) deleteForward() {
+	if s.cursor == len(s.buf) {
+		return
+	}
+	s.buf = append(s.buf[:s.cursor], s.buf[s.cursor+1:]...)
+}
+
+func (s *session) deleteWord() {
+	start := s.cursor
+	for start > 0 && s.buf[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && s.buf[start-1] != ' ' {
+		start--
+	}
+	s.buf = append(s.buf[:start], s.buf[s.cursor:]...)
+	s.cursor = start
+}
+
+func (s *session) previousHistory() {
+	if s.history == 0 {
+		return
+	}
+	if s.history == len(s.editor.history) {
+		s.draft = string(s.buf)
+	}
+	s.history--
+	s.setLine(s.editor.history[s.history])
+}
+
+func (s *session) nextHistory() {
+	if s.history == len(s.editor.history) {
+		return
+	}
+	s.history++
+	if s.history == len(s.editor.history) {
+		s.setLine(s.draft)
+		return
+	}
+	s.setLine(s.editor.history[s.history])
+}
+
+// escape handles an ANSI escape sequence such as an arrow key.
+func (s *session) escape() error {
+	key, err := readEscape(s.editor.reader)
+	if err != nil {
+		return err
+	}
+	switch key {
+	case "A":
+		s.previousHistory()
+	case "B":
+		s.nextHistory()
+	case "C":
+		s.right()
+	case "D":
+		s.left()
+	case "H", "1~", "7~":
+		s.cursor = 0
+	case "F", "4~", "8~":
+		s.cursor = len(s.buf)
+	case "3~":
+		s.deleteForward()
+	}
+	return nil
+}
+
+// readEscape consumes the rest of an escape sequence after ESC, returning its
+// parameters and final character, e.g. "A" for up or "3~" for delete.
+func readEscape(reader *bufio.Reader) (string, error) {
+	r, _, err := reader.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	if r != '[' && r != 'O' {
+		return "", nil
+	}
+	sequence := strings.Builder{}
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		sequence.WriteRune(r)
+		if r >= 0x40 && r <= 0x7e {
+			return sequence.String(), nil
+		}
+	}
+}
+
+// complete replaces the identifier before the cursor with the longest prefix
+// shared by its completions, listing them if it is still ambiguous.
+func (s *session) complete() {
+	if s.editor.Complete == nil {
+		return
+	}
+	start := s.cursor
+	for start > 0 && isWordRune(s.buf[start-1]) {
+		start--
+	}
+	word := string(s.buf[start:s.cursor])
+	candidates := s.editor.Complete(word)
+	if len(candidates) == 0 {
+		fmt.Fprint(s.editor.out, "\a")
+		return
+	}
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		prefix = commonPrefix(prefix, candidate)
+	}
+	if len(prefix) > len(word) {
+		s.insertString(strings.TrimPrefix(prefix, word))
+		return
+	}
+	if len(candidates) > 1 {
+		fmt.Fprintf(s.editor.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+// search runs a reverse incremental search through the history. Enter submits
+// the match, Ctrl-G or Ctrl-C restores the original line, and any other
+// control key leaves the match in place for editing. It reports whether the
+// line was submitted.
+func (s *session) search() (bool, error) {
+	original := string(s.buf)
+	query := ""
+	match := len(s.editor.history)
+	found := ""
+	for {
+		fmt.Fprintf(s.editor.out, "\r(reverse-i-search)`%s': %s\x1b[K", query, found)
+		r, _, err := s.editor.reader.ReadRune()
+		if err != nil {
+			return false, err
+		}
+		switch r {
+		case keyEnter, keyNewline:
+			s.setLine(found)
+			return true, nil
+		case keyCtrlG, keyCtrlC:
+			s.setLine(original)
+			return false, nil
+		case keyCtrlR:
+			match, found = s.findHistory(query, match-1)
+		case keyBackspace, keyCtrlH:
+			if query != "" {
+				runes := []rune(query)
+				query = string(runes[:len(runes)-1])
+				match, found = s.findHistory(query, len(s.editor.history)-1)
+			}
+		case keyEscape:
+			if _, err := readEscape(s.editor.reader); err != nil {
+				return false, err
+			}
+			s.setLine(found)
+			return false, nil
+		default:
+			if !unicode.IsPrint(r) {
+				s.setLine(found)
+				return false, nil
+			}
+			query += string(r)
+			from := match
+			if from == len(s.editor.history) {
+				from--
+			}
+			match, found = s.findHistory(query, from)
+		}
+	}
+}
+
+// findHistory searches backwards from index for an entry containing query,
+// returning its index and text. If there is none, the index is left past the
+// end of the history and the text is empty.
+func (s *session) findHistory(query string, from int) (int, string) {
+	for i := from; i >= 0; i-- {
+		if strings.Contains(s.editor.history[i], query) {
+			return i, s.editor.history[i]
+		}
+	}
+	return len(s.editor.history), ""
+}
@@ -0,0 +1,150 @@
+package lineedit
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		name     string
+		history  []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain line",
+			input:    "print 1;\r",
+			expected: "print 1;",
+		},
+		{
+			name:     "backspace",
+			input:    "abc\x7f\r",
+			expected: "ab",
+		},
+		{
+			name:     "insert after moving left",
+			input:    "ac\x1b[Db\r",
+			expected: "abc",
+		},
+		{
+			name:     "insert at start of line",
+			input:    "bc\x01a\r",
+			expected: "abc",
+		},
+		{
+			name:     "delete under cursor",
+			input:    "abxc\x1b[D\x1b[D\x1b[3~\r",
+			expected: "abc",
+		},
+		{
+			name:     "kill to end of line",
+			input:    "abc\x1b[D\x1b[D\x0b\r",
+			expected: "a",
+		},
+		{
+			name:     "delete word",
+			input:    "var foo\x17bar\r",
+			expected: "var bar",
+		},
+		{
+			name:     "previous history",
+			history:  []string{"one", "two"},
+			input:    "\x1b[A\x1b[A\r",
+			expected: "one",
+		},
+		{
+			name:     "next history restores draft",
+			history:  []string{"one", "two"},
+			input:    "dra\x1b[A\x1b[Bft\r",
+			expected: "draft",
+		},
+		{
+			name:     "reverse search",
+			history:  []string{"var a = 1;", "print a;", "var b = 2;"},
+			input:    "\x12var\x12\r",
+			expected: "var a = 1;",
+		},
+		{
+			name:     "reverse search then edit",
+			history:  []string{"print a;"},
+			input:    "\x12pri\x05 // done\r",
+			expected: "print a; // done",
+		},
+		{
+			name:     "tab completes unique candidate",
+			input:    "pri\t 1;\r",
+			expected: "print 1;",
+		},
+		{
+			name:     "tab completes common prefix",
+			input:    "x\t\r",
+			expected: "xyz",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := bytes.NewBuffer(nil)
+			editor := &Editor{
+				out:     output,
+				reader:  bufio.NewReader(strings.NewReader(test.input)),
+				history: test.history,
+				Complete: func(word string) []string {
+					candidates := make([]string, 0)
+					for _, name := range []string{"print", "xyz1", "xyz2"} {
+						if strings.HasPrefix(name, word) {
+							candidates = append(candidates, name)
+						}
+					}
+					return candidates
+				},
+			}
+			line, err := editor.edit("> ")
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if line != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, line)
+			}
+		})
+	}
+}
+
+func TestEditEndOfInput(t *testing.T) {
+	editor := &Editor{out: io.Discard, reader: bufio.NewReader(strings.NewReader("\x04"))}
+	if _, err := editor.edit("> "); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+	editor = &Editor{out: io.Discard, reader: bufio.NewReader(strings.NewReader("abc\x03"))}
+	if _, err := editor.edit("> "); err != ErrInterrupted {
+		t.Errorf("Expected ErrInterrupted, got %v", err)
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	editor := &Editor{out: io.Discard, reader: bufio.NewReader(strings.NewReader("three\r\x1b[A\x1b[A\r"))}
+	if err := editor.LoadHistory(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := editor.edit("> "); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	line, _ := editor.edit("> ")
+	if line != "two" {
+		t.Errorf("Expected %q, got %q", "two", line)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "one\ntwo\nthree\ntwo\n" {
+		t.Errorf("Expected history file to have the new lines, got %q", string(data))
+	}
+}
@@ -0,0 +1,10 @@
+//go:build linux
+
+package lineedit
+
+import "syscall"
+
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
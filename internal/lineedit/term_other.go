@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package lineedit
+
+import "errors"
+
+type terminalState struct{}
+
+func makeRaw(fd int) (*terminalState, error) {
+	return nil, errors.New("line editing is not supported on this platform")
+}
+
+func restore(fd int, state *terminalState) error {
+	return nil
+}
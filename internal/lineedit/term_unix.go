@@ -0,0 +1,46 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package lineedit
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type terminalState struct {
+	termios syscall.Termios
+}
+
+// makeRaw puts the terminal into raw mode so that keys are delivered one at a
+// time without echo, returning the previous state for restore. It fails if fd
+// is not a terminal.
+func makeRaw(fd int) (*terminalState, error) {
+	var termios syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, &termios); err != nil {
+		return nil, err
+	}
+	state := &terminalState{termios: termios}
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	termios.Oflag &^= syscall.OPOST
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB
+	termios.Cflag |= syscall.CS8
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, ioctlSetTermios, &termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func restore(fd int, state *terminalState) error {
+	return ioctl(fd, ioctlSetTermios, &state.termios)
+}
+
+func ioctl(fd int, request uint, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(request), uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Package optimizer rewrites parsed programs so that they do less work at
+// runtime without changing what they print or which errors they raise.
+package optimizer
+
+import (
+	"io"
+	"math"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Optimize returns an equivalent program in which constant expressions are
+// folded into literals, groups are unwrapped, and if and while statements
+// with constant conditions are replaced by the branch that would run.
+// Expressions that would fail at runtime, such as a division by zero, are
+// left in place so that the error is still raised when they are reached.
+// The variable bindings worked out by the resolver are kept as they are.
+func Optimize(statements []evaluator.Statement) []evaluator.Statement {
+	optimized := make([]evaluator.Statement, 0, len(statements))
+	for _, statement := range statements {
+		if statement = optimizeStatement(statement); statement != nil {
+			optimized = append(optimized, statement)
+		}
+	}
+	return optimized
+}
+
+func optimizeBlock(block *evaluator.BlockStatement) *evaluator.BlockStatement {
+	if block == nil {
+		return nil
+	}
+	optimized := *block
+	optimized.Statements = Optimize(block.Statements)
+	return &optimized
+}
+
+// optimizeStatement returns the optimized statement, or nil if it would never
+// do anything.
+func optimizeStatement(statement evaluator.Statement) evaluator.Statement {
+	switch s := statement.(type) {
+	case *evaluator.ExpressionStatement:
+		return &evaluator.ExpressionStatement{Expression: optimizeExpression(s.Expression), Line: s.Line}
+	case *evaluator.PrintStatement:
+		return &evaluator.PrintStatement{Expression: optimizeExpression(s.Expression), Line: s.Line}
+	case *evaluator.VarStatement:
+		if s.Expr == nil {
+			return s
+		}
+		optimized := *s
+		optimized.Expr = optimizeExpression(s.Expr)
+		return &optimized
+	case *evaluator.BlockStatement:
+		return optimizeBlock(s)
+	case *evaluator.IfStatement:
+		condition := optimizeExpression(s.Condition)
+		if literal, ok := condition.(*evaluator.ExpressionLiteral); ok {
+			// The branches are blocks, so running one in place of the if
+			// statement keeps its variables in their own scope.
+			if truthy(literal) {
+				return optimizeBlock(s.Then)
+			}
+			if s.Else == nil {
+				return nil
+			}
+			return optimizeBlock(s.Else)
+		}
+		return &evaluator.IfStatement{Condition: condition, Then: optimizeBlock(s.Then), Else: optimizeBlock(s.Else), Line: s.Line}
+	case *evaluator.WhileStatement:
+		condition := optimizeExpression(s.Condition)
+		if literal, ok := condition.(*evaluator.ExpressionLiteral); ok && !truthy(literal) {
+			return nil
+		}
+		return &evaluator.WhileStatement{Condition: condition, Body: optimizeBlock(s.Body), Line: s.Line}
+	case *evaluator.FunStatement:
+		optimized := *s
+		optimized.Body = optimizeBlock(s.Body)
+		return &optimized
+	case *evaluator.ReturnStatement:
+		return &evaluator.ReturnStatement{Expr: optimizeExpression(s.Expr), Line: s.Line}
+	}
+	return statement
+}
+
+func optimizeExpression(expression evaluator.Expression) evaluator.Expression {
+	switch e := expression.(type) {
+	case *evaluator.ExpressionGroup:
+		return optimizeExpression(e.Child)
+	case *evaluator.ExpressionUnary:
+		return fold(&evaluator.ExpressionUnary{Operator: e.Operator, Child: optimizeExpression(e.Child)})
+	case *evaluator.ExpressionBinary:
+		left := optimizeExpression(e.Left)
+		right := optimizeExpression(e.Right)
+		if literal, ok := left.(*evaluator.ExpressionLiteral); ok {
+			// A constant left operand that decides a logical operator means
+			// the right operand is never evaluated.
+			if e.Operator == evaluator.BinaryOperatorAnd && !truthy(literal) {
+				return &evaluator.ExpressionLiteral{Literal: false}
+			}
+			if e.Operator == evaluator.BinaryOperatorOr && truthy(literal) {
+				return literal
+			}
+		}
+		return fold(&evaluator.ExpressionBinary{Operator: e.Operator, Left: left, Right: right})
+	case *evaluator.ExpressionAssignment:
+		optimized := *e
+		optimized.Expr = optimizeExpression(e.Expr)
+		return &optimized
+	case *evaluator.ExpressionCall:
+		args := make([]evaluator.Expression, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, optimizeExpression(arg))
+		}
+		optimized := *e
+		optimized.Callee = optimizeExpression(e.Callee)
+		optimized.Args = args
+		return &optimized
+	case *evaluator.ExpressionGet:
+		return &evaluator.ExpressionGet{Object: optimizeExpression(e.Object), Name: e.Name}
+	}
+	return expression
+}
+
+// fold evaluates an operator whose operands are all literals, returning the
+// result as a literal. If an operand is not a literal, evaluating it fails or
+// the result is an infinite number, which a literal cannot be written as,
+// the expression is returned unchanged.
+func fold(expression evaluator.Expression) evaluator.Expression {
+	switch e := expression.(type) {
+	case *evaluator.ExpressionUnary:
+		if !isLiteral(e.Child) {
+			return e
+		}
+	case *evaluator.ExpressionBinary:
+		if !isLiteral(e.Left) || !isLiteral(e.Right) {
+			return e
+		}
+	}
+	// Operators over literals never look up variables or print, so they can
+	// be evaluated without an environment.
+	value, err := expression.Evaluate(nil, io.Discard)
+	if err != nil {
+		return expression
+	}
+	if value.Kind() == evaluator.KindObject {
+		return expression
+	}
+	if n, ok := value.AsNumber(); ok && (math.IsInf(n, 0) || math.IsNaN(n)) {
+		return expression
+	}
+	return &evaluator.ExpressionLiteral{Literal: value.Literal()}
+}
+
+func isLiteral(expression evaluator.Expression) bool {
+	_, ok := expression.(*evaluator.ExpressionLiteral)
+	return ok
+}
+
+func truthy(literal *evaluator.ExpressionLiteral) bool {
+	return evaluator.LiteralValue(literal.Literal).Bool()
+}
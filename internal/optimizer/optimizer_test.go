@@ -0,0 +1,163 @@
+package optimizer_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/astjson"
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/optimizer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+func parse(t *testing.T, program string) []evaluator.Statement {
+	t.Helper()
+	tokens, lexerErr := lexer.Tokenize(bytes.NewBufferString(program))
+	if lexerErr != nil {
+		t.Fatalf("Expected no lexer error, got %v", lexerErr)
+	}
+	statements, parserErr := parser.Parse(tokens)
+	if parserErr != nil {
+		t.Fatalf("Expected no parser error, got %v", parserErr)
+	}
+	return statements
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  string
+		expected string
+	}{
+		{
+			name:     "fold arithmetic",
+			program:  "print (60 * 60 * 24);",
+			expected: "print 86400.0",
+		},
+		{
+			name:     "fold unary and comparison",
+			program:  "print -(2) + 1 == -1; print !nil;",
+			expected: "print true\nprint true",
+		},
+		{
+			name:     "fold strings",
+			program:  "print \"a\" + \"b\";",
+			expected: "print ab",
+		},
+		{
+			name:     "fold inside variables and calls",
+			program:  "var x = 2 * 3; f(x, 1 + 1);",
+			expected: "var x = 6.0\n(expr f(x, 2.0))",
+		},
+		{
+			name:     "keep non-constant operands",
+			program:  "print x * (2 + 3);",
+			expected: "print (* x 5.0)",
+		},
+		{
+			name:     "keep division by zero",
+			program:  "print 1 / 0;",
+			expected: "print (/ 1.0 0.0)",
+		},
+		{
+			name:     "keep type errors",
+			program:  "print 1 + \"a\"; print -\"b\";",
+			expected: "print (+ 1.0 a)\nprint (- b)",
+		},
+		{
+			name:     "short circuit logical operators",
+			program:  "print false and f(); print 1 or f(); print true and f();",
+			expected: "print false\nprint 1.0\nprint (and true f())",
+		},
+		{
+			name:     "constant true branch",
+			program:  "if (1 < 2) { print 1; } else { print 2; }",
+			expected: "(block print 1.0;)",
+		},
+		{
+			name:     "constant false branch",
+			program:  "if (nil) { print 1; } else { print 2; }",
+			expected: "(block print 2.0;)",
+		},
+		{
+			name:     "constant false without else",
+			program:  "print 0; if (false) { print 1; } print 2;",
+			expected: "print 0.0\nprint 2.0",
+		},
+		{
+			name:     "keep non-constant branch",
+			program:  "if (x) { print 1 + 1; }",
+			expected: "if (x) then (block print 2.0;)",
+		},
+		{
+			name:     "drop loop that never runs",
+			program:  "while (false) { print 1; } print 2;",
+			expected: "print 2.0",
+		},
+		{
+			name:     "optimize function bodies",
+			program:  "fun f(a) { if (true) { return a * (2 * 2); } }",
+			expected: "fun f(a) (block (block return (* a 4.0););)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements := optimizer.Optimize(parse(t, test.program))
+			lines := make([]string, 0, len(statements))
+			for _, statement := range statements {
+				lines = append(lines, statement.String())
+			}
+			if actual := strings.Join(lines, "\n"); actual != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOptimizePreservesBehavior(t *testing.T) {
+	programs := []string{
+		"var total = 0; for (var i = 0; i < 3; i = i + 1) { total = total + (2 * 5); } print total;",
+		"var a = 1; if (true) { var a = 2; print a; } print a;",
+		"fun f(n) { if (false) { return 0; } return n * (1 + 1); } print f(21);",
+		"print \"x\" + (1 + 2 == 3 or nope);",
+	}
+	for _, program := range programs {
+		run := func(statements []evaluator.Statement) string {
+			env := evaluator.NewEnvironment()
+			output := bytes.NewBuffer(nil)
+			for _, statement := range statements {
+				if err := statement.Execute(env, output); err != nil {
+					output.WriteString(err.Error())
+					break
+				}
+			}
+			return output.String()
+		}
+		expected := run(parse(t, program))
+		if actual := run(optimizer.Optimize(parse(t, program))); actual != expected {
+			t.Errorf("%s: expected %q, got %q", program, expected, actual)
+		}
+	}
+}
+
+func TestOptimizeKeepsInfiniteResults(t *testing.T) {
+	// No literal can hold the product, which overflows to infinity, and
+	// astjson cannot encode one either.
+	program := "print 1" + strings.Repeat("0", 308) + " * 10; print -(1" + strings.Repeat("0", 308) + " * 10);"
+	statements := optimizer.Optimize(parse(t, program))
+	for _, statement := range statements {
+		expression := statement.(*evaluator.PrintStatement).Expression
+		if unary, ok := expression.(*evaluator.ExpressionUnary); ok {
+			expression = unary.Child
+		}
+		if _, ok := expression.(*evaluator.ExpressionBinary); !ok {
+			t.Errorf("Expected the multiplication to be kept, got %s", statement)
+		}
+	}
+	if _, err := astjson.Marshal(statements); err != nil {
+		t.Errorf("Expected the optimized program to encode, got %v", err)
+	}
+}
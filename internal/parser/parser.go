@@ -5,6 +5,7 @@ import (
 
 	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
 	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/resolver"
 )
 
 func Parse(tokens []lexer.Token) ([]evaluator.Statement, *ParserError) {
@@ -17,9 +18,24 @@ func Parse(tokens []lexer.Token) ([]evaluator.Statement, *ParserError) {
 		}
 		statements = append(statements, statement)
 	}
+	resolver.Resolve(statements)
 	return statements, nil
 }
 
+// ParseExpression parses tokens that make up exactly one expression, with no
+// trailing semicolon.
+func ParseExpression(tokens []lexer.Token) (evaluator.Expression, *ParserError) {
+	p := &parser{tokens: tokens}
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isAtEnd() {
+		return nil, NewParserError("Expected end of expression.")
+	}
+	return expr, nil
+}
+
 type parser struct {
 	tokens []lexer.Token
 	index  int
@@ -89,6 +105,7 @@ func (p *parser) statement() (evaluator.Statement, *ParserError) {
 // parameters     → IDENTIFIER ( "," IDENTIFIER )* ;
 
 func (p *parser) funStatement() (*evaluator.FunStatement, *ParserError) {
+	line := p.previous().Line
 	if !p.advanceMatch(lexer.TokenTypeIdentifier) {
 		return nil, NewParserError("Expected function name")
 	}
@@ -119,17 +136,18 @@ func (p *parser) funStatement() (*evaluator.FunStatement, *ParserError) {
 		return nil, err
 	}
 
-	return &evaluator.FunStatement{Name: name, Params: params, Body: body}, nil
+	return &evaluator.FunStatement{Name: name, Params: params, Body: body, Line: line}, nil
 }
 
 // varDecl        → "var" IDENTIFIER ( "=" expression )? ";" ;
 
 func (p *parser) varStatement() (*evaluator.VarStatement, *ParserError) {
+	line := p.previous().Line
 	if !p.advanceMatch(lexer.TokenTypeIdentifier) {
 		return nil, NewParserError("Expected variable name")
 	}
 
-	varStmt := &evaluator.VarStatement{Name: p.previous().Lexeme}
+	varStmt := &evaluator.VarStatement{Name: p.previous().Lexeme, Line: line}
 	if p.advanceMatch(lexer.TokenTypeEqual) {
 		expr, err := p.expression()
 		if err != nil {
@@ -151,6 +169,7 @@ func (p *parser) varStatement() (*evaluator.VarStatement, *ParserError) {
 // desugar to block statement with initializer and while statement
 
 func (p *parser) forStatement() (*evaluator.BlockStatement, *ParserError) {
+	line := p.previous().Line
 	if !p.advanceMatch(lexer.TokenTypeLeftParen) {
 		return nil, NewParserError("Expected '(' after 'for'")
 	}
@@ -182,6 +201,7 @@ func (p *parser) forStatement() (*evaluator.BlockStatement, *ParserError) {
 	}
 
 	var increment evaluator.Expression
+	incrementLine := p.peek().Line
 	if !p.advanceMatch(lexer.TokenTypeRightParen) {
 		inc, err := p.expression()
 		if err != nil {
@@ -202,10 +222,10 @@ func (p *parser) forStatement() (*evaluator.BlockStatement, *ParserError) {
 		return nil, err
 	}
 	if increment != nil {
-		body.Statements = append(body.Statements, &evaluator.ExpressionStatement{Expression: increment})
+		body.Statements = append(body.Statements, &evaluator.ExpressionStatement{Expression: increment, Line: incrementLine})
 	}
 
-	whileStmt := &evaluator.WhileStatement{Condition: condition, Body: body}
+	whileStmt := &evaluator.WhileStatement{Condition: condition, Body: body, Line: line}
 	if condition == nil {
 		whileStmt.Condition = &evaluator.ExpressionLiteral{Literal: true}
 	}
@@ -221,6 +241,7 @@ func (p *parser) forStatement() (*evaluator.BlockStatement, *ParserError) {
 //                ( "else" blockStmt )? ;
 
 func (p *parser) ifStatement() (*evaluator.IfStatement, *ParserError) {
+	line := p.previous().Line
 	if !p.advanceMatch(lexer.TokenTypeLeftParen) {
 		return nil, NewParserError("Expected '(' after 'if'")
 	}
@@ -248,12 +269,13 @@ func (p *parser) ifStatement() (*evaluator.IfStatement, *ParserError) {
 			return nil, err
 		}
 	}
-	return &evaluator.IfStatement{Condition: condition, Then: then, Else: elseStmt}, nil
+	return &evaluator.IfStatement{Condition: condition, Then: then, Else: elseStmt, Line: line}, nil
 }
 
 // printStmt      → "print" expression ";" ;
 
 func (p *parser) printStatement() (*evaluator.PrintStatement, *ParserError) {
+	line := p.previous().Line
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
@@ -261,12 +283,13 @@ func (p *parser) printStatement() (*evaluator.PrintStatement, *ParserError) {
 	if !p.advanceMatch(lexer.TokenTypeSemicolon) {
 		return nil, NewParserError("Expected semicolon after print statement")
 	}
-	return &evaluator.PrintStatement{Expression: expr}, nil
+	return &evaluator.PrintStatement{Expression: expr, Line: line}, nil
 }
 
 // returnStmt     → "return" expression? ";" ;
 
 func (p *parser) returnStatement() (*evaluator.ReturnStatement, *ParserError) {
+	line := p.previous().Line
 	if !p.advanceMatch(lexer.TokenTypeSemicolon) {
 		expr, err := p.expression()
 		if err != nil {
@@ -275,14 +298,15 @@ func (p *parser) returnStatement() (*evaluator.ReturnStatement, *ParserError) {
 		if !p.advanceMatch(lexer.TokenTypeSemicolon) {
 			return nil, NewParserError("Expected semicolon after return statement")
 		}
-		return &evaluator.ReturnStatement{Expr: expr}, nil
+		return &evaluator.ReturnStatement{Expr: expr, Line: line}, nil
 	}
-	return &evaluator.ReturnStatement{Expr: &evaluator.ExpressionLiteral{Literal: nil}}, nil
+	return &evaluator.ReturnStatement{Expr: &evaluator.ExpressionLiteral{Literal: nil}, Line: line}, nil
 }
 
 // whileStmt      → "while" "(" expression ")" blockStmt ;
 
 func (p *parser) whileStatement() (*evaluator.WhileStatement, *ParserError) {
+	line := p.previous().Line
 	if !p.advanceMatch(lexer.TokenTypeLeftParen) {
 		return nil, NewParserError("Expected '(' after 'while'")
 	}
@@ -300,7 +324,7 @@ func (p *parser) whileStatement() (*evaluator.WhileStatement, *ParserError) {
 	if err != nil {
 		return nil, err
 	}
-	return &evaluator.WhileStatement{Condition: condition, Body: body}, nil
+	return &evaluator.WhileStatement{Condition: condition, Body: body, Line: line}, nil
 }
 
 // blockStmt          → "{" declaration* "}" ;
@@ -323,6 +347,7 @@ func (p *parser) blockStatement() (*evaluator.BlockStatement, *ParserError) {
 // exprStmt       → expression ";" ;
 
 func (p *parser) expressionStatement() (*evaluator.ExpressionStatement, *ParserError) {
+	line := p.peek().Line
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
@@ -330,7 +355,7 @@ func (p *parser) expressionStatement() (*evaluator.ExpressionStatement, *ParserE
 	if !p.advanceMatch(lexer.TokenTypeSemicolon) {
 		return nil, NewParserError("Expected semicolon after expression statement")
 	}
-	return &evaluator.ExpressionStatement{Expression: expr}, nil
+	return &evaluator.ExpressionStatement{Expression: expr, Line: line}, nil
 }
 
 // expression     → assignment ;
@@ -344,7 +369,7 @@ func (p *parser) expressionStatement() (*evaluator.ExpressionStatement, *ParserE
 // factor         → unary ( ( "/" | "*" ) unary )* ;
 // unary          → ( "!" | "-" ) unary
 //                | call ;
-// call           → primary ( "(" arguments? ")" )* ; (arguments → expression ( "," expression )* ;)
+// call           → primary ( "(" arguments? ")" | "." IDENTIFIER )* ; (arguments → expression ( "," expression )* ;)
 // primary        → NUMBER | STRING | "true" | "false" | "nil"
 //                | "(" expression ")" ;
 
@@ -517,7 +542,7 @@ func (p *parser) unary() (evaluator.Expression, *ParserError) {
 	return p.call()
 }
 
-// call           → primary ( "(" arguments? ")" )* ; (arguments → expression ( "," expression )* ;)
+// call           → primary ( "(" arguments? ")" | "." IDENTIFIER )* ; (arguments → expression ( "," expression )* ;)
 
 func (p *parser) call() (evaluator.Expression, *ParserError) {
 	callee, err := p.primary()
@@ -525,7 +550,14 @@ func (p *parser) call() (evaluator.Expression, *ParserError) {
 		return nil, err
 	}
 
-	for p.advanceMatch(lexer.TokenTypeLeftParen) {
+	for p.advanceMatch(lexer.TokenTypeLeftParen, lexer.TokenTypeDot) {
+		if p.previous().Type == lexer.TokenTypeDot {
+			if !p.advanceMatch(lexer.TokenTypeIdentifier) {
+				return nil, NewParserError("Expected property name after '.'.")
+			}
+			callee = &evaluator.ExpressionGet{Object: callee, Name: p.previous().Lexeme}
+			continue
+		}
 		args := make([]evaluator.Expression, 0)
 		for p.peek().Type != lexer.TokenTypeRightParen {
 			arg, err := p.expression()
@@ -542,8 +574,9 @@ func (p *parser) call() (evaluator.Expression, *ParserError) {
 		}
 		_, isVar := callee.(*evaluator.ExpressionVariable)
 		_, isCall := callee.(*evaluator.ExpressionCall)
-		if !isVar && !isCall {
-			return nil, NewParserError("Callee must be an identifier or function call.")
+		_, isGet := callee.(*evaluator.ExpressionGet)
+		if !isVar && !isCall && !isGet {
+			return nil, NewParserError("Callee must be an identifier, property or function call.")
 		}
 		callee = &evaluator.ExpressionCall{Callee: callee, Args: args}
 	}
@@ -245,6 +245,26 @@ func TestParseExpression(t *testing.T) {
 			program:     "\"hello\"(1, 2)",
 			expectError: true,
 		},
+		{
+			name:     "property",
+			program:  "math.pi",
+			expected: "math.pi",
+		},
+		{
+			name:     "property call",
+			program:  "math.max(1, 2)",
+			expected: "math.max(1.0, 2.0)",
+		},
+		{
+			name:     "property of call result",
+			program:  "f().g.h()",
+			expected: "f().g.h()",
+		},
+		{
+			name:        "property missing name",
+			program:     "math.(1)",
+			expectError: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -507,3 +527,47 @@ func TestParseStatement(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBareExpression(t *testing.T) {
+	tests := []struct {
+		name        string
+		program     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "expression without semicolon",
+			program:  "1 + 2",
+			expected: "(+ 1.0 2.0)",
+		},
+		{
+			name:        "expression with semicolon",
+			program:     "1 + 2;",
+			expectError: true,
+		},
+		{
+			name:        "statement",
+			program:     "print 1",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, _ := lexer.Tokenize(bytes.NewBuffer([]byte(test.program)))
+			expr, err := ParseExpression(tokens)
+			if err != nil && !test.expectError {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if err == nil && test.expectError {
+				t.Errorf("Expected error, got nil")
+			}
+			if err != nil {
+				return
+			}
+			if expr.String() != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, expr.String())
+			}
+		})
+	}
+}
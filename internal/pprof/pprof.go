@@ -0,0 +1,188 @@
+// Package pprof writes evaluator profiles in the protocol buffer format read
+// by go tool pprof, so that the hot spots of a Lox program can be viewed with
+// the usual pprof tools. See
+// https://github.com/google/pprof/blob/main/proto/profile.proto for the
+// format.
+package pprof
+
+import (
+	"compress/gzip"
+	"io"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Field numbers of the messages in profile.proto.
+const (
+	profileSampleType        = 1
+	profileSample            = 2
+	profileLocation          = 4
+	profileFunction          = 5
+	profileStringTable       = 6
+	profileTimeNanos         = 9
+	profileDurationNanos     = 10
+	profilePeriodType        = 11
+	profilePeriod            = 12
+	profileDefaultSampleType = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// Write writes profile to w as a gzip-compressed pprof profile. Each sample
+// holds the number of calls made along a stack and the time spent at the top
+// of it. The functions in the profile are attributed to filename, the Lox
+// source file that was run.
+func Write(w io.Writer, profile *evaluator.Profile, filename string) error {
+	p := &profileBuilder{strings: map[string]int{"": 0}, stringTable: []string{""}, locations: map[evaluator.Frame]int{}}
+	file := p.string(filename)
+
+	out := &buffer{}
+	for _, valueType := range [][2]string{{"calls", "count"}, {"time", "nanoseconds"}} {
+		out.message(profileSampleType, p.valueType(valueType[0], valueType[1]))
+	}
+
+	for _, sample := range profile.Samples() {
+		ids := make([]uint64, 0, len(sample.Stack))
+		for _, frame := range sample.Stack {
+			id, ok := p.locations[frame]
+			if !ok {
+				id = len(p.locations) + 1
+				p.locations[frame] = id
+				p.location(out, id, frame, file)
+			}
+			ids = append(ids, uint64(id))
+		}
+		s := &buffer{}
+		s.packed(sampleLocationID, ids)
+		s.packed(sampleValue, []uint64{uint64(sample.Calls), uint64(sample.Time.Nanoseconds())})
+		out.message(profileSample, s)
+	}
+
+	out.varint(profileTimeNanos, uint64(profile.Start().UnixNano()))
+	out.varint(profileDurationNanos, uint64(profile.Duration().Nanoseconds()))
+	out.message(profilePeriodType, p.valueType("time", "nanoseconds"))
+	out.varint(profilePeriod, 1)
+	out.varint(profileDefaultSampleType, uint64(p.string("time")))
+	for _, s := range p.stringTable {
+		out.bytes(profileStringTable, []byte(s))
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(out.data); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// profileBuilder interns the strings of a profile and numbers its locations.
+// Each frame gets one location and one function, which share an ID.
+type profileBuilder struct {
+	strings     map[string]int
+	stringTable []string
+	locations   map[evaluator.Frame]int
+}
+
+func (p *profileBuilder) string(s string) int {
+	if i, ok := p.strings[s]; ok {
+		return i
+	}
+	p.strings[s] = len(p.stringTable)
+	p.stringTable = append(p.stringTable, s)
+	return p.strings[s]
+}
+
+func (p *profileBuilder) valueType(typ, unit string) *buffer {
+	b := &buffer{}
+	b.varint(valueTypeType, uint64(p.string(typ)))
+	b.varint(valueTypeUnit, uint64(p.string(unit)))
+	return b
+}
+
+// location writes the location and function for frame to out. Natives are
+// not declared in the source file, so they are left without one.
+func (p *profileBuilder) location(out *buffer, id int, frame evaluator.Frame, file int) {
+	name := p.string(frame.Name)
+	function := &buffer{}
+	function.varint(functionID, uint64(id))
+	function.varint(functionName, uint64(name))
+	function.varint(functionSystemName, uint64(name))
+	if frame.Line > 0 || frame == evaluator.ScriptFrame {
+		function.varint(functionFilename, uint64(file))
+		function.varint(functionStartLine, uint64(frame.Line))
+	}
+	out.message(profileFunction, function)
+
+	line := &buffer{}
+	line.varint(lineFunctionID, uint64(id))
+	line.varint(lineLine, uint64(frame.Line))
+	location := &buffer{}
+	location.varint(locationID, uint64(id))
+	location.message(locationLine, line)
+	out.message(profileLocation, location)
+}
+
+// buffer accumulates an encoded protocol buffer message.
+type buffer struct {
+	data []byte
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *buffer) uvarint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *buffer) tag(field, wireType int) {
+	b.uvarint(uint64(field)<<3 | uint64(wireType))
+}
+
+// varint writes an integer field. Zero is the default, so it is left out.
+func (b *buffer) varint(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.tag(field, wireVarint)
+	b.uvarint(x)
+}
+
+func (b *buffer) bytes(field int, data []byte) {
+	b.tag(field, wireBytes)
+	b.uvarint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *buffer) message(field int, m *buffer) {
+	b.bytes(field, m.data)
+}
+
+// packed writes a repeated integer field in packed form.
+func (b *buffer) packed(field int, xs []uint64) {
+	p := &buffer{}
+	for _, x := range xs {
+		p.uvarint(x)
+	}
+	b.bytes(field, p.data)
+}
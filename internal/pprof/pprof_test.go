@@ -0,0 +1,163 @@
+package pprof
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+// field is a decoded protocol buffer field, holding either an integer or the
+// bytes of a string, message or packed list.
+type field struct {
+	number int
+	value  uint64
+	data   []byte
+}
+
+func decode(t *testing.T, data []byte) []field {
+	t.Helper()
+	fields := []field{}
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		data = data[n:]
+		f := field{number: int(key >> 3)}
+		switch key & 7 {
+		case wireVarint:
+			f.value, n = binary.Uvarint(data)
+			data = data[n:]
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			f.data = data[n : n+int(size)]
+			data = data[n+int(size):]
+		default:
+			t.Fatalf("Unexpected wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func unpack(data []byte) []uint64 {
+	values := []uint64{}
+	for len(data) > 0 {
+		value, n := binary.Uvarint(data)
+		values = append(values, value)
+		data = data[n:]
+	}
+	return values
+}
+
+func TestWrite(t *testing.T) {
+	program := "fun nap(ms) { sleep(ms); }\nfun main() {\n  nap(5); nap(10);\n}\nmain();"
+	tokens, _ := lexer.Tokenize(bytes.NewBufferString(program))
+	statements, parserErr := parser.Parse(tokens)
+	if parserErr != nil {
+		t.Fatalf("Expected no parser error, got %v", parserErr)
+	}
+	now := time.Unix(1000, 0)
+	profile := evaluator.NewProfile(func() time.Time { return now })
+	env := evaluator.NewEnvironment()
+	env.SetProfile(profile)
+	env.Declare("sleep", evaluator.ObjectValue(&evaluator.ValueNative{Name: "sleep", Arity: 1, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+		ms, _ := args[0].AsNumber()
+		now = now.Add(time.Duration(ms) * time.Millisecond)
+		return evaluator.Nil, nil
+	}}))
+	for _, statement := range statements {
+		if err := statement.Execute(env, bytes.NewBuffer(nil)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	out := bytes.Buffer{}
+	if err := Write(&out, profile, "nap.lox"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("Expected gzip data, got %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Expected gzip data, got %v", err)
+	}
+
+	table := []string{}
+	names := map[uint64]uint64{}
+	lines := map[uint64]uint64{}
+	stacks := [][]uint64{}
+	values := [][]uint64{}
+	var timeNanos, durationNanos uint64
+	for _, f := range decode(t, data) {
+		switch f.number {
+		case profileStringTable:
+			table = append(table, string(f.data))
+		case profileFunction:
+			var id uint64
+			for _, g := range decode(t, f.data) {
+				switch g.number {
+				case functionID:
+					id = g.value
+				case functionName:
+					names[id] = g.value
+				case functionStartLine:
+					lines[id] = g.value
+				}
+			}
+		case profileSample:
+			for _, g := range decode(t, f.data) {
+				switch g.number {
+				case sampleLocationID:
+					stacks = append(stacks, unpack(g.data))
+				case sampleValue:
+					values = append(values, unpack(g.data))
+				}
+			}
+		case profileTimeNanos:
+			timeNanos = f.value
+		case profileDurationNanos:
+			durationNanos = f.value
+		}
+	}
+
+	if len(table) == 0 || table[0] != "" {
+		t.Fatalf("Expected the string table to start with an empty string, got %q", table)
+	}
+	// Each function shares its ID with the location for it.
+	actual := []string{}
+	for i, stack := range stacks {
+		frames := []string{}
+		for j := len(stack) - 1; j >= 0; j-- {
+			frames = append(frames, fmt.Sprintf("%s:%d", table[names[stack[j]]], lines[stack[j]]))
+		}
+		actual = append(actual, fmt.Sprintf("%s %v", strings.Join(frames, ";"), values[i]))
+	}
+	expected := []string{
+		"(script):0 [1 0]",
+		"(script):0;main:2 [1 0]",
+		"(script):0;main:2;nap:1 [2 0]",
+		"(script):0;main:2;nap:1;sleep:0 [2 15000000]",
+	}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Expected samples\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+	if !slices.Contains(table, "nap.lox") {
+		t.Errorf("Expected the filename in the string table, got %q", table)
+	}
+	if timeNanos != uint64(time.Unix(1000, 0).UnixNano()) {
+		t.Errorf("Expected the profile to start at 1000s, got %dns", timeNanos)
+	}
+	if durationNanos != uint64(15*time.Millisecond) {
+		t.Errorf("Expected a duration of 15ms, got %dns", durationNanos)
+	}
+}
@@ -0,0 +1,185 @@
+// Package resolver works out where each local variable in a program lives, so
+// that the evaluator can find it by position instead of by name.
+package resolver
+
+import (
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// scope tracks the variables declared directly in one block or function.
+type scope struct {
+	// slots holds every name declared in the scope, including those whose
+	// declarations come later, mapped to their slot.
+	slots map[string]int
+	// declared holds the names whose declarations have been resolved so far.
+	declared map[string]bool
+	// functions is how many functions enclose the scope.
+	functions int
+}
+
+type resolver struct {
+	scopes    []*scope
+	functions int
+}
+
+// Resolve records a Binding on every variable declaration, use and assignment
+// in the program, and the number of slots on every block. Variables that are
+// not declared in any enclosing block or function are left as globals. Calls
+// whose result a function returns are marked as tail calls.
+//
+// A use refers to the innermost declaration of its name that has already been
+// resolved, as variables are declared when their statements run. Inside a
+// function, however, a use may also refer to a declaration later on in an
+// enclosing block, since the function may well be called after that
+// declaration has run. This is what lets local functions call each other.
+// Until the declaration runs, the use still refers to the outer one.
+func Resolve(statements []evaluator.Statement) {
+	r := &resolver{}
+	r.statements(statements)
+}
+
+func (r *resolver) statements(statements []evaluator.Statement) {
+	for _, statement := range statements {
+		r.statement(statement)
+	}
+}
+
+func (r *resolver) push(s *scope) {
+	r.scopes = append(r.scopes, s)
+}
+
+func (r *resolver) pop() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *resolver) block(block *evaluator.BlockStatement) {
+	if block == nil {
+		return
+	}
+	s := &scope{slots: make(map[string]int), declared: make(map[string]bool), functions: r.functions}
+	for _, statement := range block.Statements {
+		var name string
+		switch stmt := statement.(type) {
+		case *evaluator.VarStatement:
+			name = stmt.Name
+		case *evaluator.FunStatement:
+			name = stmt.Name
+		default:
+			continue
+		}
+		if _, ok := s.slots[name]; !ok {
+			s.slots[name] = len(s.slots)
+		}
+	}
+	r.push(s)
+	r.statements(block.Statements)
+	r.pop()
+	block.Size = len(s.slots)
+}
+
+// declare marks a name in the innermost scope as declared, returning its
+// binding. At the top level, it is a global.
+func (r *resolver) declare(name string) evaluator.Binding {
+	if len(r.scopes) == 0 {
+		return evaluator.Binding{}
+	}
+	s := r.scopes[len(r.scopes)-1]
+	s.declared[name] = true
+	return evaluator.Binding{Local: true, Slot: s.slots[name]}
+}
+
+// lookup binds a use of a name to the innermost declaration that has been
+// resolved. A declaration later on in a block outside the current function is
+// taken first, with the rest of the lookup as its fallback, since the function
+// may be called either before or after that declaration has run.
+func (r *resolver) lookup(name string) evaluator.Binding {
+	return r.lookupFrom(name, len(r.scopes)-1)
+}
+
+func (r *resolver) lookupFrom(name string, i int) evaluator.Binding {
+	for ; i >= 0; i-- {
+		s := r.scopes[i]
+		slot, ok := s.slots[name]
+		if !ok {
+			continue
+		}
+		binding := evaluator.Binding{Local: true, Depth: len(r.scopes) - 1 - i, Slot: slot}
+		if s.declared[name] {
+			return binding
+		}
+		if r.functions > s.functions {
+			fallback := r.lookupFrom(name, i-1)
+			binding.Fallback = &fallback
+			return binding
+		}
+	}
+	return evaluator.Binding{}
+}
+
+// function resolves a function body. The parameters get a scope of their own
+// with one slot each, in order, as that is how calls fill them in.
+func (r *resolver) function(fun *evaluator.FunStatement) {
+	r.functions++
+	s := &scope{slots: make(map[string]int), declared: make(map[string]bool), functions: r.functions}
+	for i, param := range fun.Params {
+		s.slots[param] = i
+		s.declared[param] = true
+	}
+	r.push(s)
+	r.block(fun.Body)
+	r.pop()
+	r.functions--
+}
+
+func (r *resolver) statement(statement evaluator.Statement) {
+	switch s := statement.(type) {
+	case *evaluator.ExpressionStatement:
+		r.expression(s.Expression)
+	case *evaluator.PrintStatement:
+		r.expression(s.Expression)
+	case *evaluator.VarStatement:
+		r.expression(s.Expr)
+		s.Binding = r.declare(s.Name)
+	case *evaluator.BlockStatement:
+		r.block(s)
+	case *evaluator.IfStatement:
+		r.expression(s.Condition)
+		r.block(s.Then)
+		r.block(s.Else)
+	case *evaluator.WhileStatement:
+		r.expression(s.Condition)
+		r.block(s.Body)
+	case *evaluator.FunStatement:
+		s.Binding = r.declare(s.Name)
+		r.function(s)
+	case *evaluator.ReturnStatement:
+		if call, ok := s.Expr.(*evaluator.ExpressionCall); ok {
+			call.Tail = r.functions > 0
+		}
+		r.expression(s.Expr)
+	}
+}
+
+func (r *resolver) expression(expression evaluator.Expression) {
+	switch e := expression.(type) {
+	case *evaluator.ExpressionGroup:
+		r.expression(e.Child)
+	case *evaluator.ExpressionUnary:
+		r.expression(e.Child)
+	case *evaluator.ExpressionBinary:
+		r.expression(e.Left)
+		r.expression(e.Right)
+	case *evaluator.ExpressionVariable:
+		e.Binding = r.lookup(e.Name)
+	case *evaluator.ExpressionAssignment:
+		r.expression(e.Expr)
+		e.Binding = r.lookup(e.Name)
+	case *evaluator.ExpressionCall:
+		r.expression(e.Callee)
+		for _, arg := range e.Args {
+			r.expression(arg)
+		}
+	case *evaluator.ExpressionGet:
+		r.expression(e.Object)
+	}
+}
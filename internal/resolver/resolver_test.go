@@ -0,0 +1,162 @@
+package resolver_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+func parse(t *testing.T, program string) []evaluator.Statement {
+	t.Helper()
+	tokens, lexerErr := lexer.Tokenize(bytes.NewBufferString(program))
+	if lexerErr != nil {
+		t.Fatalf("Expected no lexer error, got %v", lexerErr)
+	}
+	statements, parserErr := parser.Parse(tokens)
+	if parserErr != nil {
+		t.Fatalf("Expected no parser error, got %v", parserErr)
+	}
+	return statements
+}
+
+func TestResolveBindings(t *testing.T) {
+	statements := parse(t, "var g = 1; { var a = g; var b = 2; { print a + b; } } fun f(x, y) { var z; return x + z; }")
+
+	global := statements[0].(*evaluator.VarStatement)
+	if global.Binding.Local {
+		t.Errorf("Expected top-level variable to be global, got %+v", global.Binding)
+	}
+
+	block := statements[1].(*evaluator.BlockStatement)
+	if block.Size != 2 {
+		t.Errorf("Expected block with 2 slots, got %d", block.Size)
+	}
+	a := block.Statements[0].(*evaluator.VarStatement)
+	assertBinding(t, "a", a.Binding, evaluator.Binding{Local: true, Depth: 0, Slot: 0})
+	assertBinding(t, "g", a.Expr.(*evaluator.ExpressionVariable).Binding, evaluator.Binding{})
+	b := block.Statements[1].(*evaluator.VarStatement)
+	assertBinding(t, "b", b.Binding, evaluator.Binding{Local: true, Depth: 0, Slot: 1})
+	sum := block.Statements[2].(*evaluator.BlockStatement).Statements[0].(*evaluator.PrintStatement).Expression.(*evaluator.ExpressionBinary)
+	assertBinding(t, "a", sum.Left.(*evaluator.ExpressionVariable).Binding, evaluator.Binding{Local: true, Depth: 1, Slot: 0})
+	assertBinding(t, "b", sum.Right.(*evaluator.ExpressionVariable).Binding, evaluator.Binding{Local: true, Depth: 1, Slot: 1})
+
+	// Parameters live in their own scope, just outside the body's block.
+	fun := statements[2].(*evaluator.FunStatement)
+	ret := fun.Body.Statements[1].(*evaluator.ReturnStatement).Expr.(*evaluator.ExpressionBinary)
+	assertBinding(t, "x", ret.Left.(*evaluator.ExpressionVariable).Binding, evaluator.Binding{Local: true, Depth: 1, Slot: 0})
+	assertBinding(t, "z", ret.Right.(*evaluator.ExpressionVariable).Binding, evaluator.Binding{Local: true, Depth: 0, Slot: 0})
+}
+
+func assertBinding(t *testing.T, name string, actual, expected evaluator.Binding) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("Expected %s to be bound to %+v, got %+v", name, expected, actual)
+	}
+}
+
+func TestResolvedPrograms(t *testing.T) {
+	tests := []struct {
+		name        string
+		program     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "shadowing",
+			program:  "var a = \"global\"; { print a; var a = \"local\"; { var a = a + \"!\"; print a; } print a; } print a;",
+			expected: "global\nlocal!\nlocal\nglobal\n",
+		},
+		{
+			name:     "redeclaration reuses the slot",
+			program:  "{ var x = 1; fun f() { return x; } var x = 2; print f(); }",
+			expected: "2\n",
+		},
+		{
+			name:     "closures keep their scope",
+			program:  "fun counter() { var n = 0; fun inc() { n = n + 1; return n; } return inc; } var a = counter(); var b = counter(); a(); print a(); print b();",
+			expected: "2\n1\n",
+		},
+		{
+			name:     "local mutual recursion",
+			program:  "fun outer() { fun isEven(n) { if (n == 0) { return true; } return isOdd(n - 1); } fun isOdd(n) { if (n == 0) { return false; } return isEven(n - 1); } return isEven(10); } print outer();",
+			expected: "true\n",
+		},
+		{
+			name:     "partial application",
+			program:  "fun add(a, b, c) { var sum = a + b + c; return sum; } var add1 = add(1); var add3 = add1(2); print add3(3); print add1(5, 6);",
+			expected: "6\n12\n",
+		},
+		{
+			name:     "loop variables",
+			program:  "var total = 0; for (var i = 0; i < 4; i = i + 1) { var square = i * i; total = total + square; } print total;",
+			expected: "14\n",
+		},
+		{
+			name:     "later declaration falls back to the global",
+			program:  "var a = \"global\"; { fun f() { print a; } f(); var a = \"local\"; f(); }",
+			expected: "global\nlocal\n",
+		},
+		{
+			name:     "later declaration falls back to the enclosing local",
+			program:  "{ var a = 1; { fun f() { a = a + 1; return a; } print f(); var a = 10; print f(); } print a; }",
+			expected: "2\n11\n2\n",
+		},
+		{
+			name:        "use before declaration in a function",
+			program:     "{ fun f() { return later; } print f(); var later = 1; }",
+			expectError: true,
+		},
+		{
+			name:        "assignment to undeclared local",
+			program:     "{ fun f() { later = 2; } f(); var later = 1; }",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			env := evaluator.NewEnvironment()
+			output := bytes.NewBuffer(nil)
+			var err *evaluator.RuntimeError
+			for _, statement := range parse(t, test.program) {
+				if err = statement.Execute(env, output); err != nil {
+					break
+				}
+			}
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if output.String() != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestResolveTailCalls(t *testing.T) {
+	statements := parse(t, "fun f() { g(); if (true) { return g(); } return g() + 1; } return g();")
+	body := statements[0].(*evaluator.FunStatement).Body.Statements
+	if body[0].(*evaluator.ExpressionStatement).Expression.(*evaluator.ExpressionCall).Tail {
+		t.Errorf("Expected a call statement not to be a tail call")
+	}
+	returned := body[1].(*evaluator.IfStatement).Then.Statements[0].(*evaluator.ReturnStatement).Expr
+	if !returned.(*evaluator.ExpressionCall).Tail {
+		t.Errorf("Expected a returned call to be a tail call")
+	}
+	sum := body[2].(*evaluator.ReturnStatement).Expr.(*evaluator.ExpressionBinary)
+	if sum.Left.(*evaluator.ExpressionCall).Tail {
+		t.Errorf("Expected a call used in a returned expression not to be a tail call")
+	}
+	if statements[1].(*evaluator.ReturnStatement).Expr.(*evaluator.ExpressionCall).Tail {
+		t.Errorf("Expected a call returned outside a function not to be a tail call")
+	}
+}
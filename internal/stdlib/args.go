@@ -0,0 +1,69 @@
+package stdlib
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+func native(name string, arity int, fn func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError)) evaluator.Value {
+	return evaluator.ObjectValue(&evaluator.ValueNative{Name: name, Arity: arity, Fn: fn})
+}
+
+func argumentError(fn string, args []evaluator.Value, i int, expected string) *evaluator.RuntimeError {
+	return evaluator.NewRuntimeError(fmt.Sprintf("%s: argument %d must be %s, got %s.", fn, i+1, expected, args[i].Type()))
+}
+
+func numberArg(fn string, args []evaluator.Value, i int) (float64, *evaluator.RuntimeError) {
+	if n, ok := args[i].AsNumber(); ok {
+		return n, nil
+	}
+	return 0, argumentError(fn, args, i, "a number")
+}
+
+func arityError(fn string, min, max int, got int) *evaluator.RuntimeError {
+	return evaluator.NewRuntimeError(fmt.Sprintf("%s: expected %d to %d arguments but got %d.", fn, min, max, got))
+}
+
+// maxSafeInteger is the largest integer below which every integer is exactly
+// representable as a number.
+const maxSafeInteger = 1 << 53
+
+// isSafeInteger reports whether n is an integer that converts to an int
+// without losing precision.
+func isSafeInteger(n float64) bool {
+	return n == math.Trunc(n) && math.Abs(n) <= maxSafeInteger
+}
+
+func intArg(fn string, args []evaluator.Value, i int) (int, *evaluator.RuntimeError) {
+	n, err := numberArg(fn, args, i)
+	if err != nil || n != math.Trunc(n) {
+		return 0, argumentError(fn, args, i, "an integer")
+	}
+	if !isSafeInteger(n) {
+		return 0, argumentError(fn, args, i, "an integer between -2^53 and 2^53")
+	}
+	return int(n), nil
+}
+
+func stringArg(fn string, args []evaluator.Value, i int) (string, *evaluator.RuntimeError) {
+	if s, ok := args[i].AsString(); ok {
+		return s, nil
+	}
+	return "", argumentError(fn, args, i, "a string")
+}
+
+func listArg(fn string, args []evaluator.Value, i int) (*evaluator.ValueList, *evaluator.RuntimeError) {
+	if list, ok := args[i].Object().(*evaluator.ValueList); ok {
+		return list, nil
+	}
+	return nil, argumentError(fn, args, i, "a list")
+}
+
+func mapArg(fn string, args []evaluator.Value, i int) (*evaluator.ValueMap, *evaluator.RuntimeError) {
+	if m, ok := args[i].Object().(*evaluator.ValueMap); ok {
+		return m, nil
+	}
+	return nil, argumentError(fn, args, i, "a map")
+}
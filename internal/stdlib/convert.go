@@ -0,0 +1,76 @@
+package stdlib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Conversions returns the global natives for converting between types and
+// inspecting values, keyed by name.
+func Conversions() map[string]evaluator.Value {
+	return map[string]evaluator.Value{
+		"num":        native("num", 1, toNumber),
+		"str":        native("str", 1, toString),
+		"bool":       native("bool", 1, toBool),
+		"type":       native("type", 1, typeOf),
+		"isCallable": native("isCallable", 1, isCallable),
+		"arity":      native("arity", 1, arity),
+	}
+}
+
+func toNumber(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	switch v := args[0].Literal().(type) {
+	case float64:
+		return args[0], nil
+	case bool:
+		if v {
+			return evaluator.Number(1), nil
+		}
+		return evaluator.Number(0), nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("num: cannot convert %q to a number.", v))
+		}
+		return evaluator.Number(n), nil
+	}
+	return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("num: cannot convert %s to a number.", args[0].Type()))
+}
+
+func toString(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	if args[0].IsNil() {
+		return evaluator.String("nil"), nil
+	}
+	return evaluator.String(args[0].String()), nil
+}
+
+func toBool(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return evaluator.Bool(args[0].Bool()), nil
+}
+
+func typeOf(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return evaluator.String(args[0].Type()), nil
+}
+
+func isCallable(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	switch args[0].Object().(type) {
+	case *evaluator.ValueClosure, *evaluator.ValueNative:
+		return evaluator.Bool(true), nil
+	}
+	return evaluator.Bool(false), nil
+}
+
+// arity returns the number of arguments a function still expects. It is -1
+// for natives that accept any number of arguments.
+func arity(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	switch fn := args[0].Object().(type) {
+	case *evaluator.ValueClosure:
+		return evaluator.Number(float64(fn.Arity())), nil
+	case *evaluator.ValueNative:
+		return evaluator.Number(float64(fn.Arity)), nil
+	}
+	return evaluator.Nil, argumentError("arity", args, 0, "a function")
+}
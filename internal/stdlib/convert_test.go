@@ -0,0 +1,60 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestConversions(t *testing.T) {
+	globals := stdlib.Conversions()
+	globals["math"] = stdlib.Math()
+	globals["list"] = stdlib.Lists()
+	runPrograms(t, globals, []programTest{
+		{
+			name:     "num",
+			program:  "print num(\"42\") + 1; print num(\" -1.5 \"); print num(3); print num(true);",
+			expected: "43\n-1.5\n3\n1\n",
+		},
+		{
+			name:        "num invalid string",
+			program:     "num(\"4x2\");",
+			expectError: "Runtime Error: num: cannot convert \"4x2\" to a number.",
+		},
+		{
+			name:        "num of nil",
+			program:     "num(nil);",
+			expectError: "Runtime Error: num: cannot convert nil to a number.",
+		},
+		{
+			name:     "str",
+			program:  "print str(1.5) + \"!\"; print str(nil); print str(true); print str(list.of(1));",
+			expected: "1.5!\nnil\ntrue\n[1]\n",
+		},
+		{
+			name:     "bool",
+			program:  "print bool(nil); print bool(0); print bool(\"\");",
+			expected: "false\ntrue\ntrue\n",
+		},
+		{
+			name:     "type",
+			program:  "fun f() {} print type(1); print type(\"a\"); print type(false); print type(nil); print type(f); print type(type); print type(math); print type(list.of());",
+			expected: "number\nstring\nbool\nnil\nfunction\nfunction\nmodule\nlist\n",
+		},
+		{
+			name:     "isCallable",
+			program:  "fun f() {} print isCallable(f); print isCallable(math.sqrt); print isCallable(1);",
+			expected: "true\ntrue\nfalse\n",
+		},
+		{
+			name:     "arity",
+			program:  "fun add(a, b) { return a + b; } print arity(add); print arity(add(1)); print arity(math.pow); print arity(math.max);",
+			expected: "2\n1\n2\n-1\n",
+		},
+		{
+			name:        "arity of non-function",
+			program:     "arity(1);",
+			expectError: "Runtime Error: arity: argument 1 must be a function, got number.",
+		},
+	})
+}
@@ -0,0 +1,249 @@
+package stdlib
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Output returns the global natives for formatted output, keyed by name.
+// Whatever they print goes to w.
+//
+// Format strings contain directives of the form %[flags][width][.precision]verb.
+// The verbs are v and s for any value, q for a quoted value, d for integers,
+// x and X for hexadecimal integers, f, e and g for numbers, and % for a
+// literal percent sign. The flags are - to left-align, 0 to pad numbers with
+// zeros, + to always show the sign and a space to leave room for it.
+func Output(w io.Writer) map[string]evaluator.Value {
+	return map[string]evaluator.Value{
+		"write": evaluator.ObjectValue(&evaluator.ValueNative{Name: "write", Arity: 1, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+			fmt.Fprint(w, display(args[0]))
+			return evaluator.Nil, nil
+		}, Cost: writeCost}),
+		"printf": evaluator.ObjectValue(&evaluator.ValueNative{Name: "printf", Arity: -1, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+			s, err := format("printf", args)
+			if err != nil {
+				return evaluator.Nil, err
+			}
+			fmt.Fprint(w, s)
+			return evaluator.Nil, nil
+		}, Cost: formatCost}),
+		"format": evaluator.ObjectValue(&evaluator.ValueNative{Name: "format", Arity: -1, Fn: func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+			s, err := format("format", args)
+			if err != nil {
+				return evaluator.Nil, err
+			}
+			return evaluator.String(s), nil
+		}, Cost: formatCost}),
+	}
+}
+
+// writeCost is the length of what write prints.
+func writeCost(args []evaluator.Value) int {
+	return len(display(args[0]))
+}
+
+// formatCost estimates the length of the string that format would build, or
+// printf print, from the format string, the widths and precisions of its
+// directives and the values they show. It is 0 if formatting would fail.
+func formatCost(args []evaluator.Value) int {
+	if len(args) == 0 {
+		return 0
+	}
+	layout, ok := args[0].AsString()
+	if !ok {
+		return 0
+	}
+	cost := len(layout)
+	values := args[1:]
+	for {
+		i := strings.IndexByte(layout, '%')
+		if i < 0 {
+			return cost
+		}
+		d, rest, err := parseDirective("format", layout[i+1:])
+		if err != nil {
+			return 0
+		}
+		layout = rest
+		width, _ := strconv.Atoi(d.width)
+		precision, _ := strconv.Atoi(d.precision)
+		cost += width + precision
+		if d.verb != '%' && len(values) > 0 {
+			cost += len(display(values[0]))
+			values = values[1:]
+		}
+	}
+}
+
+// display returns how a value reads in formatted output: nil is written as
+// nil and whole numbers are written without an exponent.
+func display(value evaluator.Value) string {
+	switch value.Kind() {
+	case evaluator.KindNil:
+		return "nil"
+	case evaluator.KindNumber:
+		if v, _ := value.AsNumber(); v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+	}
+	return value.String()
+}
+
+// maxFormatWidth bounds the width and precision of a directive. It is the
+// largest that fmt accepts.
+const maxFormatWidth = 1000000
+
+// directive is a single % directive in a format string.
+type directive struct {
+	flags     string
+	width     string
+	precision string
+	verb      rune
+}
+
+func (d directive) String() string {
+	spec := "%" + d.flags + d.width
+	if d.precision != "" {
+		spec += "." + d.precision
+	}
+	return spec + string(d.verb)
+}
+
+func format(fn string, args []evaluator.Value) (string, *evaluator.RuntimeError) {
+	if len(args) == 0 {
+		return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: expected a format string.", fn))
+	}
+	layout, err := stringArg(fn, args, 0)
+	if err != nil {
+		return "", err
+	}
+	values := args[1:]
+	out := strings.Builder{}
+	for len(layout) > 0 {
+		i := strings.IndexByte(layout, '%')
+		if i < 0 {
+			out.WriteString(layout)
+			break
+		}
+		out.WriteString(layout[:i])
+		d, rest, err := parseDirective(fn, layout[i+1:])
+		if err != nil {
+			return "", err
+		}
+		layout = rest
+		if d.verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if len(values) == 0 {
+			return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: missing argument for %s.", fn, d))
+		}
+		s, err := formatValue(fn, d, values[0])
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(s)
+		values = values[1:]
+	}
+	if len(values) > 0 {
+		return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: %d arguments left over after formatting.", fn, len(values)))
+	}
+	return out.String(), nil
+}
+
+// parseDirective reads a directive after its %, returning it and the rest of
+// the format string. It fails if the directive is incomplete or its width or
+// precision is above maxFormatWidth.
+func parseDirective(fn, s string) (directive, string, *evaluator.RuntimeError) {
+	d := directive{}
+	i := 0
+	for i < len(s) && strings.IndexByte("-+0 ", s[i]) >= 0 {
+		i++
+	}
+	d.flags = s[:i]
+	start := i
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	d.width = s[start:i]
+	if i < len(s) && s[i] == '.' {
+		i++
+		start = i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		d.precision = s[start:i]
+		if d.precision == "" {
+			d.precision = "0"
+		}
+	}
+	verb, size := utf8.DecodeRuneInString(s[i:])
+	if size == 0 {
+		return d, "", evaluator.NewRuntimeError(fmt.Sprintf("%s: incomplete directive %q.", fn, "%"+s))
+	}
+	d.verb = verb
+	for _, n := range []string{d.width, d.precision} {
+		if n == "" {
+			continue
+		}
+		if value, err := strconv.Atoi(n); err != nil || value > maxFormatWidth {
+			return d, "", evaluator.NewRuntimeError(fmt.Sprintf("%s: width and precision of %s must be at most %d.", fn, d, maxFormatWidth))
+		}
+	}
+	return d, s[i+size:], nil
+}
+
+func formatValue(fn string, d directive, value evaluator.Value) (string, *evaluator.RuntimeError) {
+	switch d.verb {
+	case 'v', 's', 'q':
+		s := display(value)
+		if d.verb == 'q' {
+			s = inspectValue(value)
+		}
+		if d.precision != "" {
+			n, _ := strconv.Atoi(d.precision)
+			if runes := []rune(s); len(runes) > n {
+				s = string(runes[:n])
+			}
+		}
+		text := directive{flags: strings.Trim(d.flags, "+0 "), width: d.width, verb: 's'}
+		return fmt.Sprintf(text.String(), s), nil
+	case 'd', 'x', 'X':
+		n, err := formatNumber(fn, d, value)
+		if err != nil {
+			return "", err
+		}
+		if !isSafeInteger(n) {
+			return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: %s expects an integer, got %s.", fn, d, display(value)))
+		}
+		return fmt.Sprintf(d.String(), int64(n)), nil
+	case 'f', 'e', 'g':
+		n, err := formatNumber(fn, d, value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(d.String(), n), nil
+	}
+	return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: unknown directive %s.", fn, d))
+}
+
+func formatNumber(fn string, d directive, value evaluator.Value) (float64, *evaluator.RuntimeError) {
+	if n, ok := value.AsNumber(); ok {
+		return n, nil
+	}
+	return 0, evaluator.NewRuntimeError(fmt.Sprintf("%s: %s expects a number, got %s.", fn, d, value.Type()))
+}
+
+// inspectValue quotes strings and shows other values as they display.
+func inspectValue(value evaluator.Value) string {
+	if s, ok := value.AsString(); ok {
+		return strconv.Quote(s)
+	}
+	return display(value)
+}
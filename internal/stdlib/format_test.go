@@ -0,0 +1,101 @@
+package stdlib_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestFormat(t *testing.T) {
+	globals := stdlib.Output(bytes.NewBuffer(nil))
+	globals["list"] = stdlib.Lists()
+	globals["string"] = stdlib.Strings()
+	runPrograms(t, globals, []programTest{
+		{
+			name:     "values",
+			program:  "print format(\"%v %s %v %v %v\", nil, \"text\", true, 10000000, list.of(1, \"a\"));",
+			expected: "nil text true 10000000 [1, \"a\"]\n",
+		},
+		{
+			name:     "quoted",
+			program:  "print format(\"%q %q\", \"hi\", 1.5);",
+			expected: "\"hi\" 1.5\n",
+		},
+		{
+			name:     "width and alignment",
+			program:  "print format(\"|%5s|%-5s|%5d|%-5d|\", \"ab\", \"ab\", 42, 42);",
+			expected: "|   ab|ab   |   42|42   |\n",
+		},
+		{
+			name:     "precision",
+			program:  "print format(\"%.2f %.3s %8.3f %.1e\", 3.14159, \"héllo\", -1.5, 12345);",
+			expected: "3.14 hél   -1.500 1.2e+04\n",
+		},
+		{
+			name:     "flags",
+			program:  "print format(\"%05d %+d % d %05.1f %x %X\", 42, 3, 3, 2.5, 255, 255);",
+			expected: "00042 +3  3 002.5 ff FF\n",
+		},
+		{
+			name:        "oversized width",
+			program:     "format(\"%5000000000s|\", \"x\");",
+			expectError: "Runtime Error: format: width and precision of %5000000000s must be at most 1000000.",
+		},
+		{
+			name:        "oversized precision",
+			program:     "format(\"%.9999999f\", 1);",
+			expectError: "Runtime Error: format: width and precision of %.9999999f must be at most 1000000.",
+		},
+		{
+			name:     "largest width",
+			program:  "print string.len(format(\"%1000000s\", \"x\"));",
+			expected: "1e+06\n",
+		},
+		{
+			name:     "percent sign",
+			program:  "print format(\"100%%\");",
+			expected: "100%\n",
+		},
+		{
+			name:        "integer directive with fraction",
+			program:     "format(\"%d\", 1.5);",
+			expectError: "Runtime Error: format: %d expects an integer, got 1.5.",
+		},
+		{
+			name:        "integer directive out of range",
+			program:     "format(\"%d\", 100000000000000000000);",
+			expectError: "Runtime Error: format: %d expects an integer, got 100000000000000000000.",
+		},
+		{
+			name:        "number directive with string",
+			program:     "format(\"%.2f\", \"x\");",
+			expectError: "Runtime Error: format: %.2f expects a number, got string.",
+		},
+		{
+			name:        "missing argument",
+			program:     "format(\"%s and %s\", 1);",
+			expectError: "Runtime Error: format: missing argument for %s.",
+		},
+		{
+			name:        "extra arguments",
+			program:     "format(\"%s\", 1, 2);",
+			expectError: "Runtime Error: format: 1 arguments left over after formatting.",
+		},
+		{
+			name:        "unknown directive",
+			program:     "format(\"%k\", 1);",
+			expectError: "Runtime Error: format: unknown directive %k.",
+		},
+		{
+			name:        "incomplete directive",
+			program:     "format(\"50%\");",
+			expectError: "Runtime Error: format: incomplete directive \"%\".",
+		},
+		{
+			name:        "format string not a string",
+			program:     "format(1);",
+			expectError: "Runtime Error: format: argument 1 must be a string, got number.",
+		},
+	})
+}
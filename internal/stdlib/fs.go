@@ -0,0 +1,220 @@
+package stdlib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// sandbox limits file system natives to paths below a set of root
+// directories. Reading and writing are allowed separately, and an empty list
+// of roots disables that kind of access entirely.
+type sandbox struct {
+	readRoots  []string
+	writeRoots []string
+}
+
+// FileSystem returns the fs module. Files can only be read below readRoots
+// and only be written or removed below writeRoots. It fails if a root is not
+// an existing directory.
+func FileSystem(readRoots, writeRoots []string) (evaluator.Value, error) {
+	read, err := resolveRoots(readRoots, "read")
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	write, err := resolveRoots(writeRoots, "write")
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	s := &sandbox{readRoots: read, writeRoots: write}
+	return evaluator.ObjectValue(&evaluator.ValueModule{Name: "fs", Members: map[string]evaluator.Value{
+		"readFile":   native("fs.readFile", 1, s.readFile),
+		"writeFile":  native("fs.writeFile", 2, s.writeFile),
+		"appendFile": native("fs.appendFile", 2, s.appendFile),
+		"exists":     native("fs.exists", 1, s.exists),
+		"listDir":    native("fs.listDir", 1, s.listDir),
+		"remove":     native("fs.remove", 1, s.remove),
+	}}), nil
+}
+
+// resolveRoots returns the absolute paths of roots with their symbolic links
+// resolved, failing on the first that is not an existing directory.
+func resolveRoots(roots []string, access string) ([]string, error) {
+	resolved := make([]string, 0, len(roots))
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("cannot allow %s access to %q: %w", access, root, err)
+		}
+		path, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			return nil, fmt.Errorf("cannot allow %s access to %q: %w", access, root, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot allow %s access to %q: %w", access, root, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("cannot allow %s access to %q: not a directory", access, root)
+		}
+		resolved = append(resolved, path)
+	}
+	return resolved, nil
+}
+
+// resolveSymlinks evaluates the symbolic links in the longest prefix of path
+// that exists, so that a link cannot be used to escape a root even when the
+// file itself is yet to be created. It fails if something in the path exists
+// but cannot be resolved, such as a link to a file that does not exist yet,
+// since creating the file would follow the link wherever it leads.
+func resolveSymlinks(path string) (string, bool) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved, true
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return "", false
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, true
+	}
+	resolved, ok := resolveSymlinks(parent)
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(resolved, filepath.Base(path)), true
+}
+
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// path returns the resolved path of the fn's first argument, failing unless
+// it lies below one of roots.
+func (s *sandbox) path(fn string, args []evaluator.Value, roots []string, access string) (string, *evaluator.RuntimeError) {
+	path, err := stringArg(fn, args, 0)
+	if err != nil {
+		return "", err
+	}
+	if len(roots) == 0 {
+		return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: file system %s access is disabled.", fn, access))
+	}
+	abs, absErr := filepath.Abs(path)
+	if absErr != nil {
+		return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: %s.", fn, absErr))
+	}
+	resolved, ok := resolveSymlinks(abs)
+	if !ok {
+		return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: %q contains a symbolic link that cannot be resolved.", fn, path))
+	}
+	for _, root := range roots {
+		if isWithin(root, resolved) {
+			return resolved, nil
+		}
+	}
+	return "", evaluator.NewRuntimeError(fmt.Sprintf("%s: %q is outside the directories allowed for %s.", fn, path, access))
+}
+
+func osError(fn string, err error) *evaluator.RuntimeError {
+	return evaluator.NewRuntimeError(fmt.Sprintf("%s: %s.", fn, err))
+}
+
+func (s *sandbox) readFile(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	path, err := s.path("fs.readFile", args, s.readRoots, "read")
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return evaluator.Nil, osError("fs.readFile", readErr)
+	}
+	return evaluator.String(string(data)), nil
+}
+
+func (s *sandbox) writeFile(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return s.write("fs.writeFile", args, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+}
+
+func (s *sandbox) appendFile(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return s.write("fs.appendFile", args, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+}
+
+func (s *sandbox) write(fn string, args []evaluator.Value, flag int) (evaluator.Value, *evaluator.RuntimeError) {
+	path, err := s.path(fn, args, s.writeRoots, "write")
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	text, err := stringArg(fn, args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	// The path was checked with its links resolved, so a link found in its
+	// place now was made since, and is not followed.
+	f, openErr := os.OpenFile(path, flag|noFollow, 0o644)
+	if openErr != nil {
+		return evaluator.Nil, osError(fn, openErr)
+	}
+	defer f.Close()
+	if _, writeErr := f.WriteString(text); writeErr != nil {
+		return evaluator.Nil, osError(fn, writeErr)
+	}
+	return evaluator.Nil, nil
+}
+
+func (s *sandbox) exists(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	path, err := s.path("fs.exists", args, s.readRoots, "read")
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	_, statErr := os.Stat(path)
+	return evaluator.Bool(statErr == nil), nil
+}
+
+// listDir returns the sorted names of the entries in a directory.
+func (s *sandbox) listDir(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	path, err := s.path("fs.listDir", args, s.readRoots, "read")
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	entries, readErr := os.ReadDir(path)
+	if readErr != nil {
+		return evaluator.Nil, osError("fs.listDir", readErr)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	elements := make([]evaluator.Value, 0, len(names))
+	for _, name := range names {
+		elements = append(elements, evaluator.String(name))
+	}
+	return evaluator.ObjectValue(&evaluator.ValueList{Elements: elements}), nil
+}
+
+// remove deletes a file or an empty directory. The allowed directories
+// themselves cannot be removed.
+func (s *sandbox) remove(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	path, err := s.path("fs.remove", args, s.writeRoots, "write")
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	for _, root := range s.writeRoots {
+		if path == root {
+			name, _ := args[0].AsString()
+			return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("fs.remove: cannot remove %q, which is an allowed directory.", name))
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return evaluator.Nil, osError("fs.remove", err)
+	}
+	return evaluator.Nil, nil
+}
@@ -0,0 +1,6 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package stdlib
+
+// noFollow is not available here, so files are opened as usual.
+const noFollow = 0
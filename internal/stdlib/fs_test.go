@@ -0,0 +1,153 @@
+package stdlib_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func fileSystem(t *testing.T, readRoots, writeRoots []string) evaluator.Value {
+	t.Helper()
+	fs, err := stdlib.FileSystem(readRoots, writeRoots)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return fs
+}
+
+func TestFileSystem(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := func(name string) string {
+		return filepath.Join(root, name)
+	}
+
+	runPrograms(t, map[string]evaluator.Value{"fs": fileSystem(t, []string{root}, []string{root})}, []programTest{
+		{
+			name:     "write and read",
+			program:  fmt.Sprintf("fs.writeFile(%q, \"hello\"); fs.appendFile(%q, \" world\"); print fs.readFile(%q);", path("a.txt"), path("a.txt"), path("a.txt")),
+			expected: "hello world\n",
+		},
+		{
+			name:     "exists and remove",
+			program:  fmt.Sprintf("fs.writeFile(%q, \"\"); print fs.exists(%q); fs.remove(%q); print fs.exists(%q);", path("b.txt"), path("b.txt"), path("b.txt"), path("b.txt")),
+			expected: "true\nfalse\n",
+		},
+		{
+			name:     "list directory",
+			program:  fmt.Sprintf("fs.writeFile(%q, \"\"); print fs.listDir(%q);", path("c.txt"), root),
+			expected: "[\"a.txt\", \"c.txt\", \"link\"]\n",
+		},
+		{
+			name:        "missing file",
+			program:     fmt.Sprintf("fs.readFile(%q);", path("missing.txt")),
+			expectError: fmt.Sprintf("Runtime Error: fs.readFile: open %s: no such file or directory.", filepath.Join(resolvedRoot, "missing.txt")),
+		},
+		{
+			name:        "path traversal",
+			program:     fmt.Sprintf("fs.readFile(%q);", filepath.Join(root, "..", filepath.Base(outside), "secret.txt")),
+			expectError: fmt.Sprintf("Runtime Error: fs.readFile: %q is outside the directories allowed for read.", filepath.Join(root, "..", filepath.Base(outside), "secret.txt")),
+		},
+		{
+			name:        "remove root",
+			program:     fmt.Sprintf("fs.remove(%q);", root),
+			expectError: fmt.Sprintf("Runtime Error: fs.remove: cannot remove %q, which is an allowed directory.", root),
+		},
+		{
+			name:        "remove root through a dot",
+			program:     fmt.Sprintf("fs.remove(%q);", root+"/."),
+			expectError: fmt.Sprintf("Runtime Error: fs.remove: cannot remove %q, which is an allowed directory.", root+"/."),
+		},
+		{
+			name:        "symlink out of root",
+			program:     fmt.Sprintf("fs.writeFile(%q, \"oops\");", path("link/new.txt")),
+			expectError: fmt.Sprintf("Runtime Error: fs.writeFile: %q is outside the directories allowed for write.", path("link/new.txt")),
+		},
+	})
+
+	runPrograms(t, map[string]evaluator.Value{"fs": fileSystem(t, []string{outside}, nil)}, []programTest{
+		{
+			name:     "read only",
+			program:  fmt.Sprintf("print fs.readFile(%q);", filepath.Join(outside, "secret.txt")),
+			expected: "secret\n",
+		},
+		{
+			name:        "write disabled",
+			program:     fmt.Sprintf("fs.writeFile(%q, \"\");", filepath.Join(outside, "secret.txt")),
+			expectError: "Runtime Error: fs.writeFile: file system write access is disabled.",
+		},
+	})
+
+	runPrograms(t, map[string]evaluator.Value{"fs": fileSystem(t, nil, nil)}, []programTest{
+		{
+			name:        "disabled by default",
+			program:     fmt.Sprintf("fs.exists(%q);", root),
+			expectError: "Runtime Error: fs.exists: file system read access is disabled.",
+		},
+	})
+}
+
+func TestFileSystemDanglingLink(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	target := filepath.Join(outside, "pwned.txt")
+	link := filepath.Join(root, "dangling")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	dirLink := filepath.Join(root, "dir")
+	if err := os.Symlink(filepath.Join(outside, "missing"), dirLink); err != nil {
+		t.Fatal(err)
+	}
+
+	runPrograms(t, map[string]evaluator.Value{"fs": fileSystem(t, []string{root}, []string{root})}, []programTest{
+		{
+			name:        "write through a dangling link",
+			program:     fmt.Sprintf("fs.writeFile(%q, \"escaped\");", link),
+			expectError: fmt.Sprintf("Runtime Error: fs.writeFile: %q contains a symbolic link that cannot be resolved.", link),
+		},
+		{
+			name:        "append through a dangling link",
+			program:     fmt.Sprintf("fs.appendFile(%q, \"escaped\");", link),
+			expectError: fmt.Sprintf("Runtime Error: fs.appendFile: %q contains a symbolic link that cannot be resolved.", link),
+		},
+		{
+			name:        "write below a dangling link",
+			program:     fmt.Sprintf("fs.writeFile(%q, \"escaped\");", filepath.Join(dirLink, "file.txt")),
+			expectError: fmt.Sprintf("Runtime Error: fs.writeFile: %q contains a symbolic link that cannot be resolved.", filepath.Join(dirLink, "file.txt")),
+		},
+	})
+	if _, err := os.Stat(target); err == nil {
+		t.Errorf("Expected no file to be created outside the root")
+	}
+}
+
+func TestFileSystemRoots(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "file.txt")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(root, "missing")
+	if _, err := stdlib.FileSystem([]string{root, missing}, nil); err == nil || !strings.Contains(err.Error(), fmt.Sprintf("cannot allow read access to %q: ", missing)) {
+		t.Errorf("Expected an error naming the missing read root, got %v", err)
+	}
+	if _, err := stdlib.FileSystem(nil, []string{file}); err == nil || err.Error() != fmt.Sprintf("cannot allow write access to %q: not a directory", file) {
+		t.Errorf("Expected an error naming the write root that is a file, got %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package stdlib
+
+import "syscall"
+
+// noFollow makes opening a file fail if it is a symbolic link.
+const noFollow = syscall.O_NOFOLLOW
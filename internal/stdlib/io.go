@@ -0,0 +1,56 @@
+package stdlib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Input returns the global natives that read from the program's input, keyed
+// by name.
+func Input(input *bufio.Reader) map[string]evaluator.Value {
+	return map[string]evaluator.Value{
+		"readLine": native("readLine", 0, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+			line, ok, err := readLine("readLine", input)
+			if err != nil || !ok {
+				return evaluator.Nil, err
+			}
+			return evaluator.String(line), nil
+		}),
+		"readAll": native("readAll", 0, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+			data, err := io.ReadAll(input)
+			if err != nil {
+				return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("readAll: %s.", err))
+			}
+			return evaluator.String(string(data)), nil
+		}),
+		"readNumber": native("readNumber", 0, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+			line, ok, err := readLine("readNumber", input)
+			if err != nil || !ok {
+				return evaluator.Nil, err
+			}
+			n, parseErr := strconv.ParseFloat(strings.TrimSpace(line), 64)
+			if parseErr != nil {
+				return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("readNumber: cannot convert %q to a number.", line))
+			}
+			return evaluator.Number(n), nil
+		}),
+	}
+}
+
+// readLine reads the next line without its line ending, reporting false once
+// the input is exhausted.
+func readLine(fn string, input *bufio.Reader) (string, bool, *evaluator.RuntimeError) {
+	line, err := input.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", false, nil
+	}
+	if err != nil && err != io.EOF {
+		return "", false, evaluator.NewRuntimeError(fmt.Sprintf("%s: %s.", fn, err))
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), true, nil
+}
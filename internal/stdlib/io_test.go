@@ -0,0 +1,60 @@
+package stdlib_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestInput(t *testing.T) {
+	tests := []struct {
+		input string
+		programTest
+	}{
+		{
+			input: "first\r\nsecond",
+			programTest: programTest{
+				name:     "read lines until nil",
+				program:  "print readLine(); print readLine(); print readLine();",
+				expected: "first\nsecond\n<nil>\n",
+			},
+		},
+		{
+			input: "a\n\nb\n",
+			programTest: programTest{
+				name:     "empty line is not end of input",
+				program:  "var line = readLine(); while (line != nil) { print \"[\" + line + \"]\"; line = readLine(); }",
+				expected: "[a]\n[]\n[b]\n",
+			},
+		},
+		{
+			input: "one\ntwo\nthree\n",
+			programTest: programTest{
+				name:     "read all after a line",
+				program:  "readLine(); print readAll(); print readAll() == \"\";",
+				expected: "two\nthree\n\ntrue\n",
+			},
+		},
+		{
+			input: " 4.5 \n10\n",
+			programTest: programTest{
+				name:     "read numbers",
+				program:  "print readNumber() + readNumber(); print readNumber();",
+				expected: "14.5\n<nil>\n",
+			},
+		},
+		{
+			input: "four\n",
+			programTest: programTest{
+				name:        "read invalid number",
+				program:     "readNumber();",
+				expectError: "Runtime Error: readNumber: cannot convert \"four\" to a number.",
+			},
+		},
+	}
+	for _, test := range tests {
+		runPrograms(t, stdlib.Input(bufio.NewReader(strings.NewReader(test.input))), []programTest{test.programTest})
+	}
+}
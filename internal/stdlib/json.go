@@ -0,0 +1,205 @@
+package stdlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// JSON returns the json module for converting between JSON text and Lox
+// values. Objects become maps, arrays become lists, and the remaining JSON
+// values become the matching literals.
+func JSON() evaluator.Value {
+	return evaluator.ObjectValue(&evaluator.ValueModule{Name: "json", Members: map[string]evaluator.Value{
+		"parse":     native("json.parse", 1, jsonParse),
+		"stringify": native("json.stringify", -1, jsonStringify),
+	}})
+}
+
+func jsonParse(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	text, err := stringArg("json.parse", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	value, decodeErr := decodeJSON(decoder)
+	if decodeErr == nil {
+		if _, extra := decoder.Token(); extra != io.EOF {
+			decodeErr = errors.New("unexpected data after top-level value")
+		}
+	}
+	if decodeErr != nil {
+		if decodeErr == io.EOF {
+			decodeErr = errors.New("unexpected end of input")
+		}
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("json.parse: invalid JSON: %s.", decodeErr))
+	}
+	return value, nil
+}
+
+// decodeJSON reads the next value from the decoder token by token so that
+// object keys keep the order they have in the text.
+func decodeJSON(decoder *json.Decoder) (evaluator.Value, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '[':
+			list := &evaluator.ValueList{Elements: make([]evaluator.Value, 0)}
+			for decoder.More() {
+				element, err := decodeJSON(decoder)
+				if err != nil {
+					return evaluator.Nil, err
+				}
+				list.Elements = append(list.Elements, element)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return evaluator.Nil, err
+			}
+			return evaluator.ObjectValue(list), nil
+		case '{':
+			m := evaluator.NewValueMap()
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return evaluator.Nil, err
+				}
+				value, err := decodeJSON(decoder)
+				if err != nil {
+					return evaluator.Nil, err
+				}
+				m.Set(key.(string), value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return evaluator.Nil, err
+			}
+			return evaluator.ObjectValue(m), nil
+		}
+		return evaluator.Nil, fmt.Errorf("unexpected %q", rune(t))
+	case float64:
+		return evaluator.Number(t), nil
+	case string:
+		return evaluator.String(t), nil
+	case bool:
+		return evaluator.Bool(t), nil
+	}
+	return evaluator.Nil, nil
+}
+
+// jsonStringify encodes a value as JSON. An optional second argument gives
+// the number of spaces to indent nested values by; without it the output is
+// compact.
+func jsonStringify(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	if len(args) < 1 || len(args) > 2 {
+		return evaluator.Nil, arityError("json.stringify", 1, 2, len(args))
+	}
+	indent := 0
+	if len(args) == 2 {
+		n, err := intArg("json.stringify", args, 1)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		if n < 0 {
+			return evaluator.Nil, argumentError("json.stringify", args, 1, "a non-negative integer")
+		}
+		indent = n
+	}
+	e := &jsonEncoder{visiting: make(map[evaluator.Object]bool)}
+	if err := e.encode(args[0]); err != nil {
+		return evaluator.Nil, err
+	}
+	if indent == 0 {
+		return evaluator.String(e.buf.String()), nil
+	}
+	out := bytes.Buffer{}
+	if err := json.Indent(&out, e.buf.Bytes(), "", strings.Repeat(" ", indent)); err != nil {
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("json.stringify: %s.", err))
+	}
+	return evaluator.String(out.String()), nil
+}
+
+// jsonEncoder writes compact JSON, tracking the lists and maps currently
+// being encoded so that a value containing itself is reported instead of
+// recursing forever.
+type jsonEncoder struct {
+	buf      bytes.Buffer
+	visiting map[evaluator.Object]bool
+}
+
+func (e *jsonEncoder) encode(value evaluator.Value) *evaluator.RuntimeError {
+	if value.Kind() != evaluator.KindObject {
+		if n, ok := value.AsNumber(); ok && (math.IsNaN(n) || math.IsInf(n, 0)) {
+			return evaluator.NewRuntimeError(fmt.Sprintf("json.stringify: cannot encode %s.", value))
+		}
+		return e.write(value.Literal())
+	}
+	switch v := value.Object().(type) {
+	case *evaluator.ValueList:
+		if err := e.enter(v); err != nil {
+			return err
+		}
+		e.buf.WriteByte('[')
+		for i, element := range v.Elements {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.encode(element); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+		delete(e.visiting, v)
+		return nil
+	case *evaluator.ValueMap:
+		if err := e.enter(v); err != nil {
+			return err
+		}
+		e.buf.WriteByte('{')
+		for i, key := range v.Keys() {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.write(key); err != nil {
+				return err
+			}
+			e.buf.WriteByte(':')
+			element, _ := v.Get(key)
+			if err := e.encode(element); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte('}')
+		delete(e.visiting, v)
+		return nil
+	}
+	return evaluator.NewRuntimeError(fmt.Sprintf("json.stringify: cannot encode a value of type %s.", value.Type()))
+}
+
+func (e *jsonEncoder) enter(value evaluator.Object) *evaluator.RuntimeError {
+	if e.visiting[value] {
+		return evaluator.NewRuntimeError(fmt.Sprintf("json.stringify: cannot encode a %s that contains itself.", value.Type()))
+	}
+	e.visiting[value] = true
+	return nil
+}
+
+// write encodes a Go value with encoding/json, leaving characters such as <
+// and & unescaped.
+func (e *jsonEncoder) write(v any) *evaluator.RuntimeError {
+	encoder := json.NewEncoder(&e.buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return evaluator.NewRuntimeError(fmt.Sprintf("json.stringify: %s.", err))
+	}
+	e.buf.Truncate(e.buf.Len() - 1)
+	return nil
+}
@@ -0,0 +1,93 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestJSON(t *testing.T) {
+	// Lox strings have no escapes, so the programs write JSON with single
+	// quotes and swap in double quotes before parsing.
+	prelude := "fun parse(s) { return json.parse(string.replace(s, \"'\", string.char(34))); }"
+	globals := map[string]evaluator.Value{
+		"json":   stdlib.JSON(),
+		"math":   stdlib.Math(),
+		"map":    stdlib.Maps(),
+		"list":   stdlib.Lists(),
+		"string": stdlib.Strings(),
+	}
+	runPrograms(t, globals, []programTest{
+		{
+			name:     "parse values",
+			program:  prelude + "var v = parse(\"{'b': [1, 2.5, 'x', true, null], 'a': {}}\"); print v; print map.get(v, \"b\");",
+			expected: "{\"b\": [1, 2.5, \"x\", true, <nil>], \"a\": {}}\n[1, 2.5, \"x\", true, <nil>]\n",
+		},
+		{
+			name:     "parse scalars",
+			program:  "print json.parse(\"-3e2\"); print json.parse(\" true \"); print json.parse(\"null\"); print json.parse(\"[]\");",
+			expected: "-300\ntrue\n<nil>\n[]\n",
+		},
+		{
+			name:     "duplicate keys keep the last value",
+			program:  prelude + "print parse(\"{'a': 1, 'b': 2, 'a': 3}\");",
+			expected: "{\"a\": 3, \"b\": 2}\n",
+		},
+		{
+			name:        "parse invalid",
+			program:     prelude + "parse(\"{'a': 1,}\");",
+			expectError: "Runtime Error: json.parse: invalid JSON: invalid character ',' looking for beginning of value.",
+		},
+		{
+			name:        "parse truncated",
+			program:     "json.parse(\"[1, 2\");",
+			expectError: "Runtime Error: json.parse: invalid JSON: unexpected end of JSON input.",
+		},
+		{
+			name:        "parse trailing data",
+			program:     "json.parse(\"[1] 2\");",
+			expectError: "Runtime Error: json.parse: invalid JSON: unexpected data after top-level value.",
+		},
+		{
+			name:     "stringify",
+			program:  "var m = map.new(); map.set(m, \"s\", \"a<b\"); map.set(m, \"l\", list.of(1, nil, false)); print json.stringify(m); print json.stringify(1.5);",
+			expected: "{\"s\":\"a<b\",\"l\":[1,null,false]}\n1.5\n",
+		},
+		{
+			name:     "stringify indented",
+			program:  "var m = map.new(); map.set(m, \"l\", list.of(1)); print json.stringify(m, 2);",
+			expected: "{\n  \"l\": [\n    1\n  ]\n}\n",
+		},
+		{
+			name:     "round trip",
+			program:  prelude + "print json.stringify(parse(\"{'a': [1, {'b': 'c'}], 'd': null}\"));",
+			expected: "{\"a\":[1,{\"b\":\"c\"}],\"d\":null}\n",
+		},
+		{
+			name:     "shared values are not cycles",
+			program:  "var xs = list.of(1); print json.stringify(list.of(xs, xs));",
+			expected: "[[1],[1]]\n",
+		},
+		{
+			name:        "cycle",
+			program:     "var m = map.new(); map.set(m, \"self\", list.of(m)); json.stringify(m);",
+			expectError: "Runtime Error: json.stringify: cannot encode a map that contains itself.",
+		},
+		{
+			name:        "closure",
+			program:     "fun f() {} json.stringify(list.of(f));",
+			expectError: "Runtime Error: json.stringify: cannot encode a value of type function.",
+		},
+		{
+			name:        "not a number",
+			program:     "json.stringify(math.sqrt(-1));",
+			expectError: "Runtime Error: json.stringify: cannot encode NaN.",
+		},
+		{
+			name:        "negative indent",
+			program:     "json.stringify(1, -1);",
+			expectError: "Runtime Error: json.stringify: argument 2 must be a non-negative integer, got number.",
+		},
+	})
+}
@@ -0,0 +1,86 @@
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Lists returns the list module for creating and working with lists, e.g.
+// list.push(list.of(1, 2), 3).
+func Lists() evaluator.Value {
+	return evaluator.ObjectValue(&evaluator.ValueModule{Name: "list", Members: map[string]evaluator.Value{
+		"of":   native("list.of", -1, listOf),
+		"len":  native("list.len", 1, listLen),
+		"get":  native("list.get", 2, listGet),
+		"set":  native("list.set", 3, listSet),
+		"push": native("list.push", 2, listPush),
+		"pop":  native("list.pop", 1, listPop),
+	}})
+}
+
+func listOf(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return evaluator.ObjectValue(&evaluator.ValueList{Elements: append([]evaluator.Value{}, args...)}), nil
+}
+
+func listLen(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	list, err := listArg("list.len", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	return evaluator.Number(float64(len(list.Elements))), nil
+}
+
+func listIndex(fn string, args []evaluator.Value) (*evaluator.ValueList, int, *evaluator.RuntimeError) {
+	list, err := listArg(fn, args, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	i, err := intArg(fn, args, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if i < 0 || i >= len(list.Elements) {
+		return nil, 0, evaluator.NewRuntimeError(fmt.Sprintf("%s: index %d is out of bounds for length %d.", fn, i, len(list.Elements)))
+	}
+	return list, i, nil
+}
+
+func listGet(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	list, i, err := listIndex("list.get", args)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	return list.Elements[i], nil
+}
+
+func listSet(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	list, i, err := listIndex("list.set", args)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	list.Elements[i] = args[2]
+	return args[2], nil
+}
+
+func listPush(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	list, err := listArg("list.push", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	list.Elements = append(list.Elements, args[1])
+	return args[0], nil
+}
+
+func listPop(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	list, err := listArg("list.pop", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if len(list.Elements) == 0 {
+		return evaluator.Nil, evaluator.NewRuntimeError("list.pop: list is empty.")
+	}
+	last := list.Elements[len(list.Elements)-1]
+	list.Elements = list.Elements[:len(list.Elements)-1]
+	return last, nil
+}
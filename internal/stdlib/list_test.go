@@ -0,0 +1,48 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestLists(t *testing.T) {
+	runPrograms(t, map[string]evaluator.Value{"list": stdlib.Lists()}, []programTest{
+		{
+			name:     "of and len",
+			program:  "var xs = list.of(1, \"a\", nil); print xs; print list.len(xs); print list.len(list.of());",
+			expected: "[1, \"a\", <nil>]\n3\n0\n",
+		},
+		{
+			name:     "get and set",
+			program:  "var xs = list.of(1, 2); list.set(xs, 0, 5); print list.get(xs, 0);",
+			expected: "5\n",
+		},
+		{
+			name:        "get out of bounds",
+			program:     "list.get(list.of(1), 1);",
+			expectError: "Runtime Error: list.get: index 1 is out of bounds for length 1.",
+		},
+		{
+			name:     "push and pop share the list",
+			program:  "var xs = list.of(); var ys = xs; list.push(xs, 1); list.push(xs, 2); print list.pop(ys); print xs;",
+			expected: "2\n[1]\n",
+		},
+		{
+			name:     "list that contains itself",
+			program:  "var a = list.of(1); list.push(a, a); print a; var b = list.of(a, a); print b;",
+			expected: "[1, [...]]\n[[1, [...]], [1, [...]]]\n",
+		},
+		{
+			name:        "pop empty",
+			program:     "list.pop(list.of());",
+			expectError: "Runtime Error: list.pop: list is empty.",
+		},
+		{
+			name:        "not a list",
+			program:     "list.len(\"abc\");",
+			expectError: "Runtime Error: list.len: argument 1 must be a list, got string.",
+		},
+	})
+}
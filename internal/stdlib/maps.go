@@ -0,0 +1,95 @@
+package stdlib
+
+import (
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Maps returns the map module for creating and working with maps from string
+// keys to values, e.g. map.set(map.new(), "a", 1).
+func Maps() evaluator.Value {
+	return evaluator.ObjectValue(&evaluator.ValueModule{Name: "map", Members: map[string]evaluator.Value{
+		"new":    native("map.new", 0, mapNew),
+		"len":    native("map.len", 1, mapLen),
+		"get":    native("map.get", 2, mapGet),
+		"set":    native("map.set", 3, mapSet),
+		"has":    native("map.has", 2, mapHas),
+		"remove": native("map.remove", 2, mapRemove),
+		"keys":   native("map.keys", 1, mapKeys),
+	}})
+}
+
+func mapNew(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return evaluator.ObjectValue(evaluator.NewValueMap()), nil
+}
+
+func mapLen(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	m, err := mapArg("map.len", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	return evaluator.Number(float64(m.Len())), nil
+}
+
+func mapKey(fn string, args []evaluator.Value) (*evaluator.ValueMap, string, *evaluator.RuntimeError) {
+	m, err := mapArg(fn, args, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := stringArg(fn, args, 1)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, key, nil
+}
+
+// mapGet returns the value for a key, or nil if the key is missing.
+func mapGet(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	m, key, err := mapKey("map.get", args)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if value, ok := m.Get(key); ok {
+		return value, nil
+	}
+	return evaluator.Nil, nil
+}
+
+func mapSet(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	m, key, err := mapKey("map.set", args)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	m.Set(key, args[2])
+	return args[2], nil
+}
+
+func mapHas(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	m, key, err := mapKey("map.has", args)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	_, ok := m.Get(key)
+	return evaluator.Bool(ok), nil
+}
+
+// mapRemove deletes a key, reporting whether it was present.
+func mapRemove(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	m, key, err := mapKey("map.remove", args)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	return evaluator.Bool(m.Delete(key)), nil
+}
+
+func mapKeys(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	m, err := mapArg("map.keys", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	keys := m.Keys()
+	elements := make([]evaluator.Value, 0, len(keys))
+	for _, key := range keys {
+		elements = append(elements, evaluator.String(key))
+	}
+	return evaluator.ObjectValue(&evaluator.ValueList{Elements: elements}), nil
+}
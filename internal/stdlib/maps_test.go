@@ -0,0 +1,43 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestMaps(t *testing.T) {
+	runPrograms(t, map[string]evaluator.Value{"map": stdlib.Maps(), "list": stdlib.Lists()}, []programTest{
+		{
+			name:     "set and get",
+			program:  "var m = map.new(); map.set(m, \"b\", 1); map.set(m, \"a\", list.of(\"x\")); print m; print map.get(m, \"b\"); print map.get(m, \"c\");",
+			expected: "{\"b\": 1, \"a\": [\"x\"]}\n1\n<nil>\n",
+		},
+		{
+			name:     "map that contains itself",
+			program:  "var m = map.new(); map.set(m, \"self\", m); print m; var xs = list.of(m); var n = map.new(); map.set(n, \"xs\", xs); list.push(xs, n); print n;",
+			expected: "{\"self\": {...}}\n{\"xs\": [{\"self\": {...}}, {...}]}\n",
+		},
+		{
+			name:     "overwrite keeps order",
+			program:  "var m = map.new(); map.set(m, \"a\", 1); map.set(m, \"b\", 2); map.set(m, \"a\", 3); print map.keys(m); print map.len(m);",
+			expected: "[\"a\", \"b\"]\n2\n",
+		},
+		{
+			name:     "has and remove",
+			program:  "var m = map.new(); map.set(m, \"a\", nil); print map.has(m, \"a\"); print map.remove(m, \"a\"); print map.remove(m, \"a\"); print map.has(m, \"a\"); print m;",
+			expected: "true\ntrue\nfalse\nfalse\n{}\n",
+		},
+		{
+			name:        "key not a string",
+			program:     "map.set(map.new(), 1, 2);",
+			expectError: "Runtime Error: map.set: argument 2 must be a string, got number.",
+		},
+		{
+			name:        "not a map",
+			program:     "map.len(list.of());",
+			expectError: "Runtime Error: map.len: argument 1 must be a map, got list.",
+		},
+	})
+}
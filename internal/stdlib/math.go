@@ -0,0 +1,97 @@
+package stdlib
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Math returns the math module, e.g. math.sqrt(2) or math.pi.
+func Math() evaluator.Value {
+	members := map[string]evaluator.Value{
+		"pi": evaluator.Number(math.Pi),
+		"e":  evaluator.Number(math.E),
+	}
+	unary := map[string]func(float64) float64{
+		"sqrt":  math.Sqrt,
+		"abs":   math.Abs,
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"round": math.Round,
+		"trunc": math.Trunc,
+		"log":   math.Log,
+		"exp":   math.Exp,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+	}
+	for name, fn := range unary {
+		members[name] = mathUnary("math."+name, fn)
+	}
+	members["pow"] = mathBinary("math.pow", math.Pow)
+	members["atan2"] = mathBinary("math.atan2", math.Atan2)
+	members["min"] = mathFold("math.min", math.Min)
+	members["max"] = mathFold("math.max", math.Max)
+	members["isNaN"] = mathPredicate("math.isNaN", math.IsNaN)
+	members["isInf"] = mathPredicate("math.isInf", func(n float64) bool { return math.IsInf(n, 0) })
+	return evaluator.ObjectValue(&evaluator.ValueModule{Name: "math", Members: members})
+}
+
+func mathUnary(name string, fn func(float64) float64) evaluator.Value {
+	return native(name, 1, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+		n, err := numberArg(name, args, 0)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		return evaluator.Number(fn(n)), nil
+	})
+}
+
+func mathBinary(name string, fn func(float64, float64) float64) evaluator.Value {
+	return native(name, 2, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+		x, err := numberArg(name, args, 0)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		y, err := numberArg(name, args, 1)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		return evaluator.Number(fn(x, y)), nil
+	})
+}
+
+// mathFold applies fn across one or more arguments, as in math.max(1, 5, 3).
+func mathFold(name string, fn func(float64, float64) float64) evaluator.Value {
+	return native(name, -1, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+		if len(args) == 0 {
+			return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("%s: expected at least 1 argument.", name))
+		}
+		result, err := numberArg(name, args, 0)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		for i := 1; i < len(args); i++ {
+			n, err := numberArg(name, args, i)
+			if err != nil {
+				return evaluator.Nil, err
+			}
+			result = fn(result, n)
+		}
+		return evaluator.Number(result), nil
+	})
+}
+
+func mathPredicate(name string, fn func(float64) bool) evaluator.Value {
+	return native(name, 1, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+		n, err := numberArg(name, args, 0)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		return evaluator.Bool(fn(n)), nil
+	})
+}
@@ -0,0 +1,73 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestMath(t *testing.T) {
+	runPrograms(t, map[string]evaluator.Value{"math": stdlib.Math()}, []programTest{
+		{
+			name:     "constants",
+			program:  "print math.pi; print math.e;",
+			expected: "3.141592653589793\n2.718281828459045\n",
+		},
+		{
+			name:     "unary functions",
+			program:  "print math.sqrt(16); print math.abs(-2); print math.floor(1.5); print math.ceil(1.5);",
+			expected: "4\n2\n1\n2\n",
+		},
+		{
+			name:     "rounding",
+			program:  "print math.round(2.5); print math.trunc(-2.7);",
+			expected: "3\n-2\n",
+		},
+		{
+			name:     "exponentials",
+			program:  "print math.pow(2, 10); print math.exp(0); print math.log(1);",
+			expected: "1024\n1\n0\n",
+		},
+		{
+			name:     "trigonometry",
+			program:  "print math.sin(0); print math.cos(0); print math.atan2(0, 1);",
+			expected: "0\n1\n0\n",
+		},
+		{
+			name:     "min and max",
+			program:  "print math.min(3, 1, 2); print math.max(3, 1, 2); print math.max(7);",
+			expected: "1\n3\n7\n",
+		},
+		{
+			name:     "nan and infinity",
+			program:  "print math.isNaN(math.sqrt(-1)); print math.isInf(math.log(0)); print math.isNaN(1);",
+			expected: "true\ntrue\nfalse\n",
+		},
+		{
+			name:     "function as value",
+			program:  "var f = math.sqrt; print f(9);",
+			expected: "3\n",
+		},
+		{
+			name:        "wrong argument type",
+			program:     "math.sqrt(\"four\");",
+			expectError: "Runtime Error: math.sqrt: argument 1 must be a number, got string.",
+		},
+		{
+			name:        "wrong argument count",
+			program:     "math.pow(2);",
+			expectError: "Runtime Error: math.pow: expected 2 arguments but got 1.",
+		},
+		{
+			name:        "min with no arguments",
+			program:     "math.min();",
+			expectError: "Runtime Error: math.min: expected at least 1 argument.",
+		},
+		{
+			name:        "undefined member",
+			program:     "math.tau;",
+			expectError: "Runtime Error: Undefined property \"tau\" on module math.",
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package stdlib
+
+import (
+	"os"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Process returns the globals describing the running program: args, the list
+// of command-line arguments given after the script, env for reading
+// environment variables and exit for stopping with a status.
+func Process(args []string) map[string]evaluator.Value {
+	elements := make([]evaluator.Value, 0, len(args))
+	for _, arg := range args {
+		elements = append(elements, evaluator.String(arg))
+	}
+	return map[string]evaluator.Value{
+		"args": evaluator.ObjectValue(&evaluator.ValueList{Elements: elements}),
+		"env":  native("env", 1, env),
+		"exit": native("exit", -1, exit),
+	}
+}
+
+// env returns the value of an environment variable, or nil if it is unset.
+func env(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	name, err := stringArg("env", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return evaluator.String(value), nil
+	}
+	return evaluator.Nil, nil
+}
+
+// exit stops the program with the given status, or 0 if there is none.
+func exit(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	if len(args) > 1 {
+		return evaluator.Nil, arityError("exit", 0, 1, len(args))
+	}
+	if len(args) == 0 {
+		return evaluator.Nil, evaluator.NewExitError(0)
+	}
+	status, err := intArg("exit", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if status < 0 || status > 255 {
+		return evaluator.Nil, argumentError("exit", args, 0, "between 0 and 255")
+	}
+	return evaluator.Nil, evaluator.NewExitError(status)
+}
@@ -0,0 +1,60 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestProcess(t *testing.T) {
+	t.Setenv("LOX_TEST_VARIABLE", "value")
+	runPrograms(t, stdlib.Process([]string{"a", "b c"}), []programTest{
+		{
+			name:     "args",
+			program:  "print args;",
+			expected: "[\"a\", \"b c\"]\n",
+		},
+		{
+			name:     "env",
+			program:  "print env(\"LOX_TEST_VARIABLE\"); print env(\"LOX_TEST_UNSET_VARIABLE\");",
+			expected: "value\n<nil>\n",
+		},
+		{
+			name:        "exit status out of range",
+			program:     "exit(256);",
+			expectError: "Runtime Error: exit: argument 1 must be between 0 and 255, got number.",
+		},
+		{
+			name:        "exit arity",
+			program:     "exit(1, 2);",
+			expectError: "Runtime Error: exit: expected 0 to 1 arguments but got 2.",
+		},
+	})
+}
+
+func TestExit(t *testing.T) {
+	exit := stdlib.Process(nil)["exit"].Object().(*evaluator.ValueNative)
+	tests := []struct {
+		name     string
+		args     []evaluator.Value
+		expected int
+	}{
+		{"no status", []evaluator.Value{}, 0},
+		{"status", []evaluator.Value{evaluator.Number(3)}, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := exit.Fn(test.args)
+			if err == nil {
+				t.Fatalf("Expected exit error, got nil")
+			}
+			if err.Code() != test.expected {
+				t.Errorf("Expected code %d, got %d", test.expected, err.Code())
+			}
+			if err.Error() != "" {
+				t.Errorf("Expected no message, got %q", err.Error())
+			}
+		})
+	}
+}
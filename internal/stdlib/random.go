@@ -0,0 +1,72 @@
+package stdlib
+
+import (
+	"math/rand/v2"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// Random returns the global natives that draw from rng, keyed by name. Each
+// interpreter passes its own generator so that seeded runs are reproducible.
+func Random(rng *rand.Rand) map[string]evaluator.Value {
+	r := &randomizer{rng: rng}
+	return map[string]evaluator.Value{
+		"random":    native("random", 0, r.random),
+		"randomInt": native("randomInt", 2, r.randomInt),
+		"shuffle":   native("shuffle", 1, r.shuffle),
+		"choice":    native("choice", 1, r.choice),
+	}
+}
+
+type randomizer struct {
+	rng *rand.Rand
+}
+
+// random returns a number in [0, 1).
+func (r *randomizer) random(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return evaluator.Number(r.rng.Float64()), nil
+}
+
+// randomInt returns an integer from lo to hi, including both.
+func (r *randomizer) randomInt(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	lo, err := intArg("randomInt", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	hi, err := intArg("randomInt", args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if hi < lo {
+		return evaluator.Nil, argumentError("randomInt", args, 1, "at least the first argument")
+	}
+	// The bounds are safe integers, so the size of the range fits in an
+	// int, but keep it to 2^53 so that IntN can never be given too much.
+	if hi-lo >= maxSafeInteger {
+		return evaluator.Nil, argumentError("randomInt", args, 1, "less than 2^53 above the first argument")
+	}
+	return evaluator.Number(float64(lo + r.rng.IntN(hi-lo+1))), nil
+}
+
+// shuffle reorders a list in place and returns it.
+func (r *randomizer) shuffle(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	list, err := listArg("shuffle", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	r.rng.Shuffle(len(list.Elements), func(i, j int) {
+		list.Elements[i], list.Elements[j] = list.Elements[j], list.Elements[i]
+	})
+	return args[0], nil
+}
+
+func (r *randomizer) choice(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	list, err := listArg("choice", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if len(list.Elements) == 0 {
+		return evaluator.Nil, evaluator.NewRuntimeError("choice: list is empty.")
+	}
+	return list.Elements[r.rng.IntN(len(list.Elements))], nil
+}
@@ -0,0 +1,90 @@
+package stdlib_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestRandom(t *testing.T) {
+	globals := stdlib.Random(rand.New(rand.NewPCG(1, 2)))
+	globals["list"] = stdlib.Lists()
+	globals["math"] = stdlib.Math()
+	runPrograms(t, globals, []programTest{
+		{
+			name:     "random is in range",
+			program:  "var ok = true; var i = 0; while (i < 100) { var r = random(); if (r < 0 or r >= 1) { ok = false; } i = i + 1; } print ok;",
+			expected: "true\n",
+		},
+		{
+			name:     "randomInt includes both bounds",
+			program:  "var lo = false; var hi = false; var out = false; var i = 0; while (i < 200) { var r = randomInt(1, 3); if (r == 1) { lo = true; } if (r == 3) { hi = true; } if (r < 1 or r > 3 or r != math.floor(r)) { out = true; } i = i + 1; } print lo and hi and !out;",
+			expected: "true\n",
+		},
+		{
+			name:     "randomInt with equal bounds",
+			program:  "print randomInt(5, 5);",
+			expected: "5\n",
+		},
+		{
+			name:        "randomInt with reversed bounds",
+			program:     "randomInt(3, 1);",
+			expectError: "Runtime Error: randomInt: argument 2 must be at least the first argument, got number.",
+		},
+		{
+			name:        "randomInt with huge bounds",
+			program:     "randomInt(-9000000000000000000, 9000000000000000000);",
+			expectError: "Runtime Error: randomInt: argument 1 must be an integer between -2^53 and 2^53, got number.",
+		},
+		{
+			name:        "randomInt with too wide a range",
+			program:     "randomInt(-9007199254740992, 9007199254740992);",
+			expectError: "Runtime Error: randomInt: argument 2 must be less than 2^53 above the first argument, got number.",
+		},
+		{
+			name:     "randomInt with the widest range",
+			program:  "var r = randomInt(0, 9007199254740991); print r >= 0 and r <= 9007199254740991 and r == math.floor(r);",
+			expected: "true\n",
+		},
+		{
+			name:     "shuffle keeps the elements",
+			program:  "var xs = list.of(1, 2, 3); var ys = shuffle(xs); list.push(ys, 4); print list.len(xs); list.pop(xs); var sum = list.get(xs, 0) + list.get(xs, 1) + list.get(xs, 2); print sum;",
+			expected: "4\n6\n",
+		},
+		{
+			name:     "choice",
+			program:  "print choice(list.of(\"only\"));",
+			expected: "only\n",
+		},
+		{
+			name:        "choice from empty list",
+			program:     "choice(list.of());",
+			expectError: "Runtime Error: choice: list is empty.",
+		},
+	})
+}
+
+func TestRandomSeed(t *testing.T) {
+	draw := func(seed uint64) []float64 {
+		random := stdlib.Random(rand.New(rand.NewPCG(seed, seed)))["random"].Object().(*evaluator.ValueNative)
+		numbers := make([]float64, 0, 3)
+		for range 3 {
+			value, _ := random.Fn(nil)
+			n, _ := value.AsNumber()
+			numbers = append(numbers, n)
+		}
+		return numbers
+	}
+	first, second, other := draw(7), draw(7), draw(8)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("Expected the same numbers for the same seed, got %v and %v", first, second)
+			break
+		}
+	}
+	if first[0] == other[0] && first[1] == other[1] && first[2] == other[2] {
+		t.Errorf("Expected different numbers for different seeds, got %v", first)
+	}
+}
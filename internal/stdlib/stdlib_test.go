@@ -0,0 +1,58 @@
+package stdlib_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/lexer"
+	"github.com/thebenkogan/lox-interpreter/internal/parser"
+)
+
+type programTest struct {
+	name        string
+	program     string
+	expected    string
+	expectError string
+}
+
+// runPrograms executes each test program with the given globals declared and
+// compares what it prints, or the error it fails with.
+func runPrograms(t *testing.T, globals map[string]evaluator.Value, tests []programTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, _ := lexer.Tokenize(bytes.NewBufferString(test.program))
+			statements, parserErr := parser.Parse(tokens)
+			if parserErr != nil {
+				t.Fatalf("Expected no parser error, got %v", parserErr)
+			}
+			env := evaluator.NewEnvironment()
+			for name, value := range globals {
+				env.Declare(name, value)
+			}
+			output := bytes.NewBuffer(nil)
+			var err *evaluator.RuntimeError
+			for _, statement := range statements {
+				if err = statement.Execute(env, output); err != nil {
+					break
+				}
+			}
+			if test.expectError != "" {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				} else if err.Error() != test.expectError {
+					t.Errorf("Expected error %q, got %q", test.expectError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+				return
+			}
+			if output.String() != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, output.String())
+			}
+		})
+	}
+}
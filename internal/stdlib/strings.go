@@ -0,0 +1,275 @@
+package stdlib
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// maxStringLength bounds the strings that natives which multiply their input
+// will build, so that a single call cannot exhaust the host's memory before
+// any memory limit is checked.
+const maxStringLength = 1 << 28
+
+// Strings returns the string module. Lengths and indexes count characters
+// (runes) rather than bytes, e.g. string.len("héllo") is 5.
+func Strings() evaluator.Value {
+	return evaluator.ObjectValue(&evaluator.ValueModule{Name: "string", Members: map[string]evaluator.Value{
+		"len":        native("string.len", 1, stringLen),
+		"substring":  native("string.substring", 3, stringSubstring),
+		"slice":      native("string.slice", -1, stringSlice),
+		"indexOf":    native("string.indexOf", 2, stringIndexOf),
+		"contains":   stringPredicate("string.contains", strings.Contains),
+		"startsWith": stringPredicate("string.startsWith", strings.HasPrefix),
+		"endsWith":   stringPredicate("string.endsWith", strings.HasSuffix),
+		"upper":      stringMap("string.upper", strings.ToUpper),
+		"lower":      stringMap("string.lower", strings.ToLower),
+		"trim":       stringMap("string.trim", strings.TrimSpace),
+		"replace":    evaluator.ObjectValue(&evaluator.ValueNative{Name: "string.replace", Arity: 3, Fn: stringReplace, Cost: stringReplaceCost}),
+		"split":      native("string.split", 2, stringSplit),
+		"join":       native("string.join", 2, stringJoin),
+		"repeat":     evaluator.ObjectValue(&evaluator.ValueNative{Name: "string.repeat", Arity: 2, Fn: stringRepeat, Cost: stringRepeatCost}),
+		"char":       native("string.char", 1, stringChar),
+		"code":       native("string.code", 1, stringCode),
+	}})
+}
+
+func stringLen(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	s, err := stringArg("string.len", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	return evaluator.Number(float64(utf8.RuneCountInString(s))), nil
+}
+
+// stringSubstring returns the characters from start up to but not including
+// end, failing if either is out of range.
+func stringSubstring(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	s, err := stringArg("string.substring", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	start, err := intArg("string.substring", args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	end, err := intArg("string.substring", args, 2)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	runes := []rune(s)
+	if start < 0 || end > len(runes) || start > end {
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("string.substring: range %d to %d is out of bounds for length %d.", start, end, len(runes)))
+	}
+	return evaluator.String(string(runes[start:end])), nil
+}
+
+// stringSlice is a forgiving substring: the end is optional, negative indexes
+// count back from the end of the string and out of range indexes are clamped.
+func stringSlice(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	if len(args) < 2 || len(args) > 3 {
+		return evaluator.Nil, arityError("string.slice", 2, 3, len(args))
+	}
+	s, err := stringArg("string.slice", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	runes := []rune(s)
+	start, err := intArg("string.slice", args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	end := len(runes)
+	if len(args) == 3 {
+		end, err = intArg("string.slice", args, 2)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+	}
+	start, end = clampIndex(start, len(runes)), clampIndex(end, len(runes))
+	if start >= end {
+		return evaluator.String(""), nil
+	}
+	return evaluator.String(string(runes[start:end])), nil
+}
+
+func clampIndex(i, length int) int {
+	if i < 0 {
+		i += length
+	}
+	return max(0, min(i, length))
+}
+
+func stringIndexOf(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	s, err := stringArg("string.indexOf", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	sub, err := stringArg("string.indexOf", args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	i := strings.Index(s, sub)
+	if i < 0 {
+		return evaluator.Number(-1), nil
+	}
+	return evaluator.Number(float64(utf8.RuneCountInString(s[:i]))), nil
+}
+
+func stringPredicate(name string, fn func(string, string) bool) evaluator.Value {
+	return native(name, 2, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+		s, err := stringArg(name, args, 0)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		other, err := stringArg(name, args, 1)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		return evaluator.Bool(fn(s, other)), nil
+	})
+}
+
+func stringMap(name string, fn func(string) string) evaluator.Value {
+	return native(name, 1, func(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+		s, err := stringArg(name, args, 0)
+		if err != nil {
+			return evaluator.Nil, err
+		}
+		return evaluator.String(fn(s)), nil
+	})
+}
+
+func stringReplace(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	s, err := stringArg("string.replace", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	old, err := stringArg("string.replace", args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	replacement, err := stringArg("string.replace", args, 2)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if n := strings.Count(s, old); len(replacement) > len(old) && n > (maxStringLength-len(s))/(len(replacement)-len(old)) {
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("string.replace: result would be longer than %d bytes.", maxStringLength))
+	}
+	return evaluator.String(strings.ReplaceAll(s, old, replacement)), nil
+}
+
+// stringReplaceCost is the length of the string that string.replace would
+// build, or 0 if its arguments are not all strings.
+func stringReplaceCost(args []evaluator.Value) int {
+	s, ok := args[0].AsString()
+	old, ok2 := args[1].AsString()
+	replacement, ok3 := args[2].AsString()
+	if !ok || !ok2 || !ok3 {
+		return 0
+	}
+	if len(replacement) <= len(old) {
+		return len(s)
+	}
+	n := strings.Count(s, old)
+	if n > (maxStringLength-len(s))/(len(replacement)-len(old)) {
+		return 0
+	}
+	return len(s) + n*(len(replacement)-len(old))
+}
+
+// stringSplit splits a string around each separator into a list. An empty
+// separator splits it into characters.
+func stringSplit(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	s, err := stringArg("string.split", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	sep, err := stringArg("string.split", args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	parts := strings.Split(s, sep)
+	elements := make([]evaluator.Value, 0, len(parts))
+	for _, part := range parts {
+		elements = append(elements, evaluator.String(part))
+	}
+	return evaluator.ObjectValue(&evaluator.ValueList{Elements: elements}), nil
+}
+
+func stringJoin(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	list, err := listArg("string.join", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	sep, err := stringArg("string.join", args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	parts := make([]string, 0, len(list.Elements))
+	for i, element := range list.Elements {
+		part, err := stringArg("string.join", list.Elements, i)
+		if err != nil {
+			return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("string.join: element %d must be a string, got %s.", i, element.Type()))
+		}
+		parts = append(parts, part)
+	}
+	return evaluator.String(strings.Join(parts, sep)), nil
+}
+
+func stringRepeat(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	s, err := stringArg("string.repeat", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	count, err := intArg("string.repeat", args, 1)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if count < 0 {
+		return evaluator.Nil, evaluator.NewRuntimeError("string.repeat: count must not be negative.")
+	}
+	if len(s) > 0 && count > maxStringLength/len(s) {
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("string.repeat: result would be longer than %d bytes.", maxStringLength))
+	}
+	return evaluator.String(strings.Repeat(s, count)), nil
+}
+
+// stringRepeatCost is the length of the string that string.repeat would
+// build, or 0 if it would fail instead.
+func stringRepeatCost(args []evaluator.Value) int {
+	s, ok := args[0].AsString()
+	count, ok2 := args[1].AsNumber()
+	if !ok || !ok2 || count < 0 || len(s) > 0 && count > float64(maxStringLength/len(s)) {
+		return 0
+	}
+	return len(s) * int(count)
+}
+
+// stringChar returns the one character string for a Unicode code point.
+func stringChar(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	code, err := intArg("string.char", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if code < 0 || code > unicode.MaxRune || !utf8.ValidRune(rune(code)) {
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("string.char: %d is not a valid code point.", code))
+	}
+	return evaluator.String(string(rune(code))), nil
+}
+
+// stringCode returns the Unicode code point of a one character string.
+func stringCode(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	s, err := stringArg("string.code", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if utf8.RuneCountInString(s) != 1 {
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("string.code: expected a single character, got %q.", s))
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return evaluator.Number(float64(r)), nil
+}
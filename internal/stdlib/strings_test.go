@@ -0,0 +1,113 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+	"github.com/thebenkogan/lox-interpreter/internal/stdlib"
+)
+
+func TestStrings(t *testing.T) {
+	runPrograms(t, map[string]evaluator.Value{"string": stdlib.Strings(), "list": stdlib.Lists()}, []programTest{
+		{
+			name:     "length counts characters",
+			program:  "print string.len(\"héllo\"); print string.len(\"\");",
+			expected: "5\n0\n",
+		},
+		{
+			name:     "substring",
+			program:  "print string.substring(\"héllo\", 1, 3);",
+			expected: "él\n",
+		},
+		{
+			name:        "substring out of range",
+			program:     "string.substring(\"abc\", 1, 4);",
+			expectError: "Runtime Error: string.substring: range 1 to 4 is out of bounds for length 3.",
+		},
+		{
+			name:     "slice",
+			program:  "print string.slice(\"hello\", 1); print string.slice(\"hello\", -3, -1); print string.slice(\"hello\", 3, 100); print string.slice(\"hello\", 4, 2);",
+			expected: "ello\nll\nlo\n\n",
+		},
+		{
+			name:        "slice arity",
+			program:     "string.slice(\"hello\");",
+			expectError: "Runtime Error: string.slice: expected 2 to 3 arguments but got 1.",
+		},
+		{
+			name:     "index of",
+			program:  "print string.indexOf(\"日本語\", \"語\"); print string.indexOf(\"abc\", \"d\");",
+			expected: "2\n-1\n",
+		},
+		{
+			name:     "predicates",
+			program:  "print string.contains(\"hello\", \"ell\"); print string.startsWith(\"hello\", \"he\"); print string.endsWith(\"hello\", \"he\");",
+			expected: "true\ntrue\nfalse\n",
+		},
+		{
+			name:     "case and trim",
+			program:  "print string.upper(\"héllo\"); print string.lower(\"ÀB\"); print string.trim(\"  x \");",
+			expected: "HÉLLO\nàb\nx\n",
+		},
+		{
+			name:     "replace",
+			program:  "print string.replace(\"a-b-c\", \"-\", \"+\");",
+			expected: "a+b+c\n",
+		},
+		{
+			name:     "split",
+			program:  "print string.split(\"a,b,,c\", \",\"); print string.split(\"hé\", \"\");",
+			expected: "[\"a\", \"b\", \"\", \"c\"]\n[\"h\", \"é\"]\n",
+		},
+		{
+			name:     "split then join",
+			program:  "var parts = string.split(\"a b c\", \" \"); print list.len(parts); print string.join(parts, \"-\");",
+			expected: "3\na-b-c\n",
+		},
+		{
+			name:        "join non-string element",
+			program:     "string.join(list.of(\"a\", 1), \",\");",
+			expectError: "Runtime Error: string.join: element 1 must be a string, got number.",
+		},
+		{
+			name:     "repeat",
+			program:  "print string.repeat(\"ab\", 3); print string.repeat(\"ab\", 0);",
+			expected: "ababab\n\n",
+		},
+		{
+			name:        "repeat fractional count",
+			program:     "string.repeat(\"ab\", 1.5);",
+			expectError: "Runtime Error: string.repeat: argument 2 must be an integer, got number.",
+		},
+		{
+			name:        "repeat too long",
+			program:     "string.repeat(\"ab\", 1000000000);",
+			expectError: "Runtime Error: string.repeat: result would be longer than 268435456 bytes.",
+		},
+		{
+			name:     "code points",
+			program:  "print string.code(\"é\"); print string.char(233); print string.char(string.code(\"a\") + 1);",
+			expected: "233\né\nb\n",
+		},
+		{
+			name:        "char out of range",
+			program:     "string.char(4294967361);",
+			expectError: "Runtime Error: string.char: 4294967361 is not a valid code point.",
+		},
+		{
+			name:        "char too large to be an integer",
+			program:     "string.char(100000000000000000000);",
+			expectError: "Runtime Error: string.char: argument 1 must be an integer between -2^53 and 2^53, got number.",
+		},
+		{
+			name:        "code of several characters",
+			program:     "string.code(\"ab\");",
+			expectError: "Runtime Error: string.code: expected a single character, got \"ab\".",
+		},
+		{
+			name:        "wrong argument type",
+			program:     "string.upper(1);",
+			expectError: "Runtime Error: string.upper: argument 1 must be a string, got number.",
+		},
+	})
+}
@@ -0,0 +1,178 @@
+package stdlib
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/thebenkogan/lox-interpreter/internal/evaluator"
+)
+
+// isoLayout is the default layout for formatting and parsing dates, RFC 3339
+// with milliseconds.
+const isoLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Clock tells the time and waits. Programs use the system clock unless the
+// interpreter is given another one, such as a fake clock in tests.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+// SystemClock returns the clock backed by the operating system, in local time.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Time returns the global natives that read the clock, keyed by name, along
+// with the time module for working with dates and durations. Times are
+// numbers of milliseconds since the Unix epoch, and durations are numbers of
+// milliseconds. Layouts are written as in Go, e.g. "2006-01-02 15:04".
+func Time(clock Clock) map[string]evaluator.Value {
+	t := &timer{clock: clock}
+	return map[string]evaluator.Value{
+		"clock": native("clock", 0, t.seconds),
+		"now":   native("now", 0, t.now),
+		"sleep": native("sleep", 1, t.sleep),
+		"time": evaluator.ObjectValue(&evaluator.ValueModule{Name: "time", Members: map[string]evaluator.Value{
+			"iso":            evaluator.String(isoLayout),
+			"format":         native("time.format", -1, t.format),
+			"parse":          native("time.parse", -1, t.parse),
+			"duration":       native("time.duration", 1, duration),
+			"formatDuration": native("time.formatDuration", 1, formatDuration),
+		}}),
+	}
+}
+
+type timer struct {
+	clock Clock
+}
+
+// maxTimestamp is the furthest from the Unix epoch, in milliseconds, that
+// times are formatted, which is 100,000,000 days either way as in JavaScript.
+const maxTimestamp = 8.64e15
+
+func milliseconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) + float64(t.Nanosecond()%int(time.Millisecond))/float64(time.Millisecond)
+}
+
+// maxDuration is the longest duration, in milliseconds, that a
+// time.Duration can hold.
+const maxDuration = math.MaxInt64 / float64(time.Millisecond)
+
+func fromMilliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// durationArg returns the duration given in milliseconds as argument i,
+// failing if it is not a number a time.Duration can hold.
+func durationArg(fn string, args []evaluator.Value, i int) (time.Duration, *evaluator.RuntimeError) {
+	ms, err := numberArg(fn, args, i)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(ms) || math.Abs(ms) >= maxDuration {
+		return 0, argumentError(fn, args, i, "a duration within 9.2e12 milliseconds either way")
+	}
+	return fromMilliseconds(ms), nil
+}
+
+// seconds returns the seconds since the Unix epoch, like clock() in the book.
+func (t *timer) seconds(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return evaluator.Number(float64(t.clock.Now().UnixNano()) / float64(time.Second)), nil
+}
+
+func (t *timer) now(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	return evaluator.Number(milliseconds(t.clock.Now())), nil
+}
+
+func (t *timer) sleep(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	d, err := durationArg("sleep", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if d < 0 {
+		return evaluator.Nil, argumentError("sleep", args, 0, "a non-negative number")
+	}
+	t.clock.Sleep(d)
+	return evaluator.Nil, nil
+}
+
+// layoutArg returns the optional layout given as the second argument.
+func layoutArg(fn string, args []evaluator.Value, fallback string) (string, *evaluator.RuntimeError) {
+	if len(args) < 1 || len(args) > 2 {
+		return "", arityError(fn, 1, 2, len(args))
+	}
+	if len(args) == 1 {
+		return fallback, nil
+	}
+	return stringArg(fn, args, 1)
+}
+
+// format formats a time in the clock's time zone.
+func (t *timer) format(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	layout, err := layoutArg("time.format", args, isoLayout)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	ms, err := numberArg("time.format", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	if math.IsNaN(ms) || math.Abs(ms) > maxTimestamp {
+		return evaluator.Nil, argumentError("time.format", args, 0, "a time within 8.64e15 milliseconds of 1970")
+	}
+	whole := math.Floor(ms)
+	date := time.UnixMilli(int64(whole)).Add(fromMilliseconds(ms - whole)).In(t.clock.Now().Location())
+	return evaluator.String(date.Format(layout)), nil
+}
+
+// parse parses a date, taking it to be in the clock's time zone unless the
+// layout includes one. By default it accepts RFC 3339 dates with or without
+// fractional seconds.
+func (t *timer) parse(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	layout, err := layoutArg("time.parse", args, time.RFC3339)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	text, err := stringArg("time.parse", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	date, parseErr := time.ParseInLocation(layout, text, t.clock.Now().Location())
+	if parseErr != nil {
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("time.parse: cannot parse %q with layout %q.", text, layout))
+	}
+	return evaluator.Number(milliseconds(date)), nil
+}
+
+// duration converts text such as "1h30m" into milliseconds.
+func duration(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	text, err := stringArg("time.duration", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	d, parseErr := time.ParseDuration(text)
+	if parseErr != nil {
+		return evaluator.Nil, evaluator.NewRuntimeError(fmt.Sprintf("time.duration: invalid duration %q.", text))
+	}
+	return evaluator.Number(float64(d) / float64(time.Millisecond)), nil
+}
+
+func formatDuration(args []evaluator.Value) (evaluator.Value, *evaluator.RuntimeError) {
+	d, err := durationArg("time.formatDuration", args, 0)
+	if err != nil {
+		return evaluator.Nil, err
+	}
+	return evaluator.String(d.String()), nil
+}